@@ -58,5 +58,23 @@ func main() {
 	fmt.Print(generator.GenerateJSON())
 	fmt.Println()
 
+	fmt.Print(generator.GenerateYAML())
+	fmt.Println()
+
+	fmt.Print(generator.GenerateProperties())
+	fmt.Println()
+
+	fmt.Print(generator.GenerateCSV())
+	fmt.Println()
+
+	fmt.Print(generator.GenerateShellOutput())
+	fmt.Println()
+
+	fmt.Print(generator.GenerateEncoding())
+	fmt.Println()
+
+	fmt.Print(generator.GenerateDatetime())
+	fmt.Println()
+
 	fmt.Print(generator.GenerateEntryPoint())
 }
@@ -15,6 +15,143 @@
 
 package generator
 
+// awkArithmetic holds the awk functions behind yq_arithmetic. yq_calc returns
+// the formatted result of "l op r", or "" when dividing by zero. Integers are
+// kept as decimal strings so they never lose precision in awk doubles.
+const awkArithmetic = `
+    function big_abs(a) {
+        sub(/^[-+]/, "", a)
+        sub(/^0+/, "", a)
+        return (a == "") ? "0" : a
+    }
+    function big_signed(neg, a) {
+        return (neg && a != "0") ? "-" a : a
+    }
+    function big_cmp_abs(a, b) {
+        if (length(a) != length(b)) return (length(a) < length(b)) ? -1 : 1
+        a = "" a
+        b = "" b
+        return (a < b) ? -1 : ((a > b) ? 1 : 0)
+    }
+    function big_add_abs(a, b,    i, j, carry, d, out) {
+        out = ""
+        carry = 0
+        i = length(a)
+        j = length(b)
+        while (i > 0 || j > 0 || carry) {
+            d = carry
+            if (i > 0) d += substr(a, i, 1)
+            if (j > 0) d += substr(b, j, 1)
+            out = (d % 10) out
+            carry = int(d / 10)
+            i--
+            j--
+        }
+        return big_abs(out)
+    }
+    function big_sub_abs(a, b,    i, j, borrow, d, out) {
+        out = ""
+        borrow = 0
+        j = length(b)
+        for (i = length(a); i > 0; i--) {
+            d = substr(a, i, 1) - borrow
+            if (j > 0) d -= substr(b, j, 1)
+            borrow = (d < 0)
+            if (borrow) d += 10
+            out = d out
+            j--
+        }
+        return big_abs(out)
+    }
+    function big_add(x, y,    nx, ny, ax, ay) {
+        nx = (x ~ /^-/)
+        ny = (y ~ /^-/)
+        ax = big_abs(x)
+        ay = big_abs(y)
+        if (nx == ny) return big_signed(nx, big_add_abs(ax, ay))
+        if (big_cmp_abs(ax, ay) >= 0) return big_signed(nx, big_sub_abs(ax, ay))
+        return big_signed(ny, big_sub_abs(ay, ax))
+    }
+    function big_mul(x, y,    ax, ay, la, lb, i, j, d, carry, out) {
+        ax = big_abs(x)
+        ay = big_abs(y)
+        la = length(ax)
+        lb = length(ay)
+        for (i = 1; i <= la + lb; i++) d[i] = 0
+        for (i = la; i > 0; i--)
+            for (j = lb; j > 0; j--)
+                d[i + j] += substr(ax, i, 1) * substr(ay, j, 1)
+        out = ""
+        carry = 0
+        for (i = la + lb; i > 0; i--) {
+            d[i] += carry
+            out = (d[i] % 10) out
+            carry = int(d[i] / 10)
+        }
+        return big_signed((x ~ /^-/) != (y ~ /^-/), big_abs(out))
+    }
+    # Truncating division by a divisor small enough for exact doubles
+    # Sets big_rem to the remainder, which takes the sign of x
+    function big_div_small(x, y,    ax, dv, i, rem, out, q) {
+        ax = big_abs(x)
+        dv = big_abs(y) + 0
+        out = ""
+        rem = 0
+        for (i = 1; i <= length(ax); i++) {
+            rem = rem * 10 + substr(ax, i, 1)
+            q = int(rem / dv)
+            rem -= q * dv
+            out = out q
+        }
+        big_rem = big_signed(x ~ /^-/, sprintf("%.0f", rem))
+        return big_signed((x ~ /^-/) != (y ~ /^-/), big_abs(out))
+    }
+    # Shortest decimal form that reads back as the same double, without exponent
+    function fmt_float(v,    p, s, m, e, digits) {
+        if (v == int(v) && v < 1e15 && v > -1e15) return sprintf("%.0f", v)
+        for (p = 1; p <= 17; p++) {
+            s = sprintf("%." p "g", v)
+            if (s + 0 == v) break
+        }
+        if (s ~ /[eE]/) {
+            m = s
+            sub(/[eE].*/, "", m)
+            e = s
+            sub(/^[^eE]*[eE]/, "", e)
+            gsub(/[-+.]/, "", m)
+            digits = length(m) - 1 - e
+            if (digits < 0) digits = 0
+            s = sprintf("%." digits "f", v)
+        }
+        return s
+    }
+    function yq_calc(op, l, r,    q) {
+        gsub(/_/, "", l)
+        gsub(/_/, "", r)
+        if (l ~ /^[-+]?[0-9]+$/ && r ~ /^[-+]?[0-9]+$/) {
+            if (op == "+") return big_add(l, r)
+            if (op == "-") return big_add(l, (r ~ /^-/) ? big_abs(r) : "-" big_abs(r))
+            if (op == "*") return big_mul(l, r)
+            if (big_abs(r) == "0") return ""
+            if (length(big_abs(r)) <= 13) {
+                q = big_div_small(l, r)
+                if (op == "%") return big_rem
+                if (big_rem == "0") return q
+            } else if (op == "%" && length(big_abs(l)) <= 15) {
+                return sprintf("%.0f", (l + 0) - int((l + 0) / (r + 0)) * (r + 0))
+            }
+        }
+        l += 0
+        r += 0
+        if (op == "+") return fmt_float(l + r)
+        if (op == "-") return fmt_float(l - r)
+        if (op == "*") return fmt_float(l * r)
+        if (r == 0) return ""
+        if (op == "/") return fmt_float(l / r)
+        return fmt_float(l - int(l / r) * r)
+    }
+`
+
 // GenerateAdvancedFunctions returns advanced manipulation functions
 func GenerateAdvancedFunctions() string {
 	return `
@@ -23,14 +160,16 @@ yq_map() {
     _expr="$1"
     _file="$2"
 
-    # Create temporary file with grep results to avoid subshell issues
-    _tmp_grep=$(mktemp -p "$_YQ_TEMP_DIR")
-    grep '^-' "$_file" > "$_tmp_grep"
+    # Split the sequence into one file per element
+    _map_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _map_count=$(yq_split_items "$_file" "$_map_base")
 
     # Iterate over array elements
     _first=1
-    while IFS= read -r _line || [ -n "$_line" ]; do
-        _value=$(echo "$_line" | sed 's/^- //')
+    _map_i=0
+    while [ "$_map_i" -lt "$_map_count" ]; do
+        _map_i=$((_map_i + 1))
+        _value=$(cat "$_map_base.$_map_i")
 
         # Apply expression to value
         _result=""
@@ -43,14 +182,11 @@ yq_map() {
         elif echo "$_expr" | grep -q '^\. + [0-9]*$'; then
             _addend=$(echo "$_expr" | sed 's/^\. + //')
             _result=$((_value + _addend))
-        # Handle key access like ".name"
-        elif echo "$_expr" | grep -q '^\.[a-zA-Z_]'; then
-            _tmp_item=$(mktemp -p "$_YQ_TEMP_DIR")
-            echo "$_value" > "$_tmp_item"
-            _result=$(yq_parse "$_expr" "$_tmp_item")
-            rm -f "$_tmp_item"
         else
-            _result="$_value"
+            # Any other expression: collect its results for every element
+            rm -f "$_map_base"*
+            yq_collect ".[] | $_expr" "$_file"
+            return
         fi
 
         if [ "$_first" -eq 1 ]; then
@@ -59,16 +195,775 @@ yq_map() {
         else
             printf "\n%s" "- $_result"
         fi
-    done < "$_tmp_grep"
+    done
 
     # Add trailing newline if we printed anything
     if [ "$_first" -eq 0 ]; then
         printf "\n"
     fi
 
-    rm -f "$_tmp_grep"
+    rm -f "$_map_base"*
+}
+
+# Collect function - gather every result of an expression into a sequence
+# Used for [expr]; an empty expression or one without results gives []
+yq_collect() {
+    _col_expr="$1"
+    _col_file="$2"
+
+    _col_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _col_count=0
+    if [ -n "$(printf '%s' "$_col_expr" | tr -d '[:space:]')" ]; then
+        _yq_parse_result "$_col_expr" "$_col_file" > "$_col_base.res"
+        _col_count=$(yq_split_results "$_col_base.res" "$_col_base.item")
+    fi
+
+    # An error inside the brackets leaves nothing to collect
+    if _yq_raised; then
+        rm -f "$_col_base"*
+        return 1
+    fi
+
+    if [ "$_col_count" -eq 0 ]; then
+        echo "[]"
+        rm -f "$_col_base"*
+        return
+    fi
+
+    _col_i=1
+    while [ "$_col_i" -le "$_col_count" ]; do
+        _yq_seq_item "$_col_base.item.$_col_i"
+        _col_i=$((_col_i + 1))
+    done
+
+    rm -f "$_col_base"*
+}
+
+# Print a node as a sequence item, continuation lines indented under the dash
+_yq_seq_item() {
+    awk '
+    NR == 1 { print "- " $0; next }
+    { print ($0 == "" ? "" : "  " $0) }
+    ' "$1"
+}
+
+# Join function - concatenate the scalar items of a sequence with a separator
+yq_join() {
+    _join_sep="$1"
+    _join_file="$2"
+
+    _join_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _join_count=$(yq_split_items "$_join_file" "$_join_base")
+    _join_i=1
+    while [ "$_join_i" -le "$_join_count" ]; do
+        [ "$_join_i" -gt 1 ] && printf '%s' "$_join_sep"
+        _join_value=$(yq_unquote "$(cat "$_join_base.$_join_i")")
+        [ "$_join_value" = "null" ] && _join_value=""
+        printf '%s' "$_join_value"
+        _join_i=$((_join_i + 1))
+    done
+    printf '\n'
+
+    rm -f "$_join_base"*
+}
+
+# Sort function - order the scalar items of a sequence
+# Numbers sort numerically before strings, strings sort by their unquoted text
+yq_sort() {
+    _sort_file="$1"
+
+    _sort_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _sort_count=$(yq_split_items "$_sort_file" "$_sort_base")
+    if [ "$_sort_count" -eq 0 ]; then
+        echo "[]"
+        rm -f "$_sort_base"*
+        return
+    fi
+
+    _sort_i=1
+    while [ "$_sort_i" -le "$_sort_count" ]; do
+        _sort_value=$(cat "$_sort_base.$_sort_i")
+        _sort_text=$(yq_unquote "$_sort_value")
+        if printf '%s\n' "$_sort_value" | grep -q '^-\{0,1\}[0-9][0-9]*\(\.[0-9]*\)\{0,1\}$'; then
+            printf '0\t%s\t%s\n' "$_sort_value" "$_sort_value"
+        else
+            printf '1\t0\t%s\t%s\n' "$_sort_text" "$_sort_value"
+        fi
+        _sort_i=$((_sort_i + 1))
+    done | sort -t "$(printf '\t')" -k1,1n -k2,2n -k3,3 | awk -F '\t' '{ print "- " $NF }'
+
+    rm -f "$_sort_base"*
+}
+
+# Split the items of a sequence or mapping into base.item.N and print the
+# numbers of the items a condition is truthy for. Each item is evaluated in
+# its own context
+_yq_matching_items() (
+    _cond="$1"
+    _file="$2"
+    _base="$3"
+
+    _kind=$(yq_node_kind "$_file")
+    [ "$_kind" = "scalar" ] && return
+    _count=$(yq_split_items "$_file" "$_base.item")
+    [ "$_kind" = "map" ] && yq_map_keys "$_file" > "$_base.keys"
+    _ctx="$_yq_ctx"
+    _i=1
+    while [ "$_i" -le "$_count" ]; do
+        if [ "$_kind" = "map" ]; then
+            _yq_ctx=$(_yq_ctx_join "$_ctx" ".$(sed -n "${_i}p" "$_base.keys")")
+        else
+            _yq_ctx=$(_yq_ctx_join "$_ctx" "[$((_i - 1))]")
+        fi
+        _yq_parse_result "$_cond" "$_base.item.$_i" > "$_base.match"
+        _yq_truthy "$_base.match" && echo "$_i"
+        _i=$((_i + 1))
+    done
+    rm -f "$_base.match" "$_base.keys"
+)
+
+# First function - first(cond) is the first item the condition holds for,
+# nothing when there is none
+yq_first_match() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _i=$(_yq_matching_items "$1" "$2" "$_base" | head -n 1)
+    [ -n "$_i" ] && cat "$_base.item.$_i"
+    rm -f "$_base"*
+)
+
+# Any and all functions - any_c(cond) is true when the condition holds for
+# some item, all_c(cond) when it holds for every item (any and all test the
+# items themselves). An empty collection is false for any, true for all
+yq_any_all() (
+    _mode="$1"
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _matches=$(_yq_matching_items "$2" "$3" "$_base" | wc -l)
+    _count=0
+    [ "$(yq_node_kind "$3")" != "scalar" ] && _count=$(yq_split_items "$3" "$_base.all")
+    if [ "$_mode" = "any" ]; then
+        [ "$_matches" -gt 0 ] && echo "true" || echo "false"
+    else
+        [ "$_matches" -eq "$_count" ] && echo "true" || echo "false"
+    fi
+    rm -f "$_base"*
+)
+
+# Limit function - limit(n; expr) keeps the first n results of expr
+yq_limit() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _n=$(_yq_parse_result "$1" "$3" | tr -d '[:space:]')
+    _yq_parse_result "$2" "$3" > "$_base.results"
+    _count=$(yq_split_results "$_base.results" "$_base.result")
+    _i=1
+    while [ "$_i" -le "$_count" ] && [ "$_i" -le "${_n:-0}" ]; do
+        [ "$_i" -gt 1 ] && echo ""
+        cat "$_base.result.$_i"
+        _i=$((_i + 1))
+    done
+    rm -f "$_base"*
+)
+
+# Range function - range(upto), range(from; upto) and range(from; upto; by)
+# print the numbers from "from" (0) up to but excluding "upto", one result each
+yq_range() (
+    _args="$1"
+    _file="$2"
+
+    _from=0
+    _by=1
+    if _yq_split_top "$_args" ";"; then
+        _from=$(_yq_parse_result "$_yq_top_left" "$_file")
+        _args="$_yq_top_right"
+        if _yq_split_top "$_args" ";"; then
+            _upto=$(_yq_parse_result "$_yq_top_left" "$_file")
+            _by=$(_yq_parse_result "$_yq_top_right" "$_file")
+        else
+            _upto=$(_yq_parse_result "$_args" "$_file")
+        fi
+    else
+        _upto=$(_yq_parse_result "$_args" "$_file")
+    fi
+
+    awk -v from="$_from" -v upto="$_upto" -v by="$_by" '` + awkArithmetic + `
+    BEGIN {
+        from += 0
+        upto += 0
+        by += 0
+        n = 0
+        for (v = from; (by > 0 && v < upto) || (by < 0 && v > upto); v += by) {
+            if (n++ > 0) print ""
+            print fmt_float(v)
+        }
+    }
+    '
+)
+
+# Index functions - the positions of a substring in a string, or of a value
+# (or a run of values, given as a sequence) in a sequence
+# mode is index (first), rindex (last) or indices (all, as a sequence)
+yq_indices() (
+    _mode="$1"
+    _file="$3"
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$2" "$_file" > "$_base.arg"
+    _kind=$(yq_node_kind "$_file")
+
+    if [ "$_kind" = "seq" ]; then
+        # One line per item, compared by value as in array subtraction
+        _count=$(yq_split_items "$_file" "$_base.item")
+        _i=1
+        while [ "$_i" -le "$_count" ]; do
+            yq_unquote "$(cat "$_base.item.$_i")" | tr '\n' '\036'
+            echo
+            _i=$((_i + 1))
+        done > "$_base.hay"
+        if [ "$(yq_node_kind "$_base.arg")" = "seq" ]; then
+            _count=$(yq_split_items "$_base.arg" "$_base.want")
+        else
+            cp "$_base.arg" "$_base.want.1"
+            _count=1
+        fi
+        _i=1
+        while [ "$_i" -le "$_count" ]; do
+            yq_unquote "$(cat "$_base.want.$_i")" | tr '\n' '\036'
+            echo
+            _i=$((_i + 1))
+        done > "$_base.needle"
+        awk -v needle="$_base.needle" '
+        BEGIN {
+            while ((getline line < needle) > 0) want[++nw] = line
+        }
+        {
+            hay[NR] = $0
+        }
+        END {
+            for (i = 1; nw > 0 && i + nw - 1 <= NR; i++) {
+                for (j = 1; j <= nw && hay[i + j - 1] == want[j]; j++) ;
+                if (j > nw) print i - 1
+            }
+        }
+        ' "$_base.hay" > "$_base.found"
+    elif [ "$_kind" = "scalar" ] && [ "$(yq_tag "$_file")" != "!!null" ]; then
+        _hay=$(yq_unquote "$(cat "$_file")")
+        _needle=$(yq_unquote "$(cat "$_base.arg")")
+        awk -v hay="$_hay" -v needle="$_needle" '
+        BEGIN {
+            if (needle == "") exit
+            for (i = 1; i + length(needle) - 1 <= length(hay); i++) {
+                if (substr(hay, i, length(needle)) == needle) print i - 1
+            }
+        }
+        ' > "$_base.found"
+    else
+        echo "null"
+        rm -f "$_base"*
+        return
+    fi
+
+    case "$_mode" in
+        index) _pos=$(head -n 1 "$_base.found") ;;
+        rindex) _pos=$(tail -n 1 "$_base.found") ;;
+    esac
+    if [ "$_mode" = "indices" ]; then
+        if [ -s "$_base.found" ]; then
+            sed 's/^/- /' "$_base.found"
+        else
+            echo "[]"
+        fi
+    else
+        echo "${_pos:-null}"
+    fi
+    rm -f "$_base"*
+)
+
+# Object construction - build maps from {key: expr, ...}
+# Keys are literals, quoted strings or (expr); {name} is short for {name: .name}
+# Every key and value result is combined, so an expression yielding several
+# results produces one object per combination, separated by blank lines
+yq_construct_object() {
+    _obj_body="$1"
+    _obj_file="$2"
+
+    if [ -z "$(printf '%s' "$_obj_body" | tr -d '[:space:]')" ]; then
+        echo "{}"
+        return
+    fi
+
+    _obj_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    : > "$_obj_base.acc.1"
+    _obj_count=1
+
+    _obj_rest="$_obj_body"
+    while [ -n "$_obj_rest" ]; do
+        if _yq_split_top "$_obj_rest" ","; then
+            _obj_entry="$_yq_top_left"
+            _obj_rest="$_yq_top_right"
+        else
+            _obj_entry="$_obj_rest"
+            _obj_rest=""
+        fi
+        _obj_entry=$(printf '%s' "$_obj_entry" | sed 's/^[[:space:]]*//; s/[[:space:]]*$//')
+        [ -z "$_obj_entry" ] && continue
+
+        if _yq_split_top "$_obj_entry" ":"; then
+            _obj_kexpr=$(printf '%s' "$_yq_top_left" | sed 's/[[:space:]]*$//')
+            _obj_vexpr="$_yq_top_right"
+        else
+            _obj_kexpr="${_obj_entry#.}"
+            _obj_vexpr=".$_obj_kexpr"
+        fi
+
+        # Resolve the key expression to one or more key names
+        case "$_obj_kexpr" in
+            \(*)
+                _obj_kexpr=$(printf '%s' "$_obj_kexpr" | sed 's/^(//; s/)$//')
+                _yq_parse_result "$_obj_kexpr" "$_obj_file" > "$_obj_base.kres"
+                _obj_nkeys=$(yq_split_results "$_obj_base.kres" "$_obj_base.key")
+                ;;
+            *)
+                printf '%s\n' "$_obj_kexpr" > "$_obj_base.key.1"
+                _obj_nkeys=1
+                ;;
+        esac
+
+        # Evaluate the value against the same input
+        _yq_parse_result "$_obj_vexpr" "$_obj_file" > "$_obj_base.vres"
+        _obj_nvals=$(yq_split_results "$_obj_base.vres" "$_obj_base.val")
+        if _yq_raised; then
+            rm -f "$_obj_base"*
+            return 1
+        fi
+
+        # Combine every object built so far with every key/value pair
+        _obj_next=0
+        _obj_i=1
+        while [ "$_obj_i" -le "$_obj_count" ]; do
+            _obj_k=1
+            while [ "$_obj_k" -le "$_obj_nkeys" ]; do
+                _obj_key=$(yq_unquote "$(cat "$_obj_base.key.$_obj_k")")
+                _obj_v=1
+                while [ "$_obj_v" -le "$_obj_nvals" ]; do
+                    _obj_next=$((_obj_next + 1))
+                    cat "$_obj_base.acc.$_obj_i" > "$_obj_base.next.$_obj_next"
+                    _yq_object_entry "$_obj_key" "$_obj_base.val.$_obj_v" >> "$_obj_base.next.$_obj_next"
+                    _obj_v=$((_obj_v + 1))
+                done
+                _obj_k=$((_obj_k + 1))
+            done
+            _obj_i=$((_obj_i + 1))
+        done
+
+        rm -f "$_obj_base.acc."* "$_obj_base.key."* "$_obj_base.val."*
+        _obj_i=1
+        while [ "$_obj_i" -le "$_obj_next" ]; do
+            mv "$_obj_base.next.$_obj_i" "$_obj_base.acc.$_obj_i"
+            _obj_i=$((_obj_i + 1))
+        done
+        _obj_count=$_obj_next
+    done
+
+    _obj_i=1
+    while [ "$_obj_i" -le "$_obj_count" ]; do
+        [ "$_obj_i" -gt 1 ] && echo ""
+        cat "$_obj_base.acc.$_obj_i"
+        _obj_i=$((_obj_i + 1))
+    done
+
+    rm -f "$_obj_base"*
+}
+
+# Print one "key: value" mapping entry, nesting collections under the key
+_yq_object_entry() {
+    awk -v key="$1" '` + awkMapKey + `
+    {
+        lines[NR] = $0
+    }
+    END {
+        k = key
+        if (k == "" || k ~ /^[-?:,\[\]{}#&*!|>'"'"'"%@]/ || k ~ /: |:$| #/ ||
+            k ~ /^(true|false|null|~|[-+]?[0-9][0-9_]*(\.[0-9]*)?([eE][-+]?[0-9]+)?|[-+]?\.[0-9]+)$/) {
+            gsub(/\\/, "\\\\\\\\", k)
+            gsub(/"/, "\\\"", k)
+            k = "\"" k "\""
+        }
+        first = lines[1]
+        if (NR == 0) {
+            print k ": null"
+        } else if (first ~ /^[|>][-+0-9]*[[:space:]]*$/) {
+            # Block scalar: the body is already indented under the header
+            print k ": " first
+            for (i = 2; i <= NR; i++) print lines[i]
+        } else if (NR == 1 && first !~ /^-( |$)/ && yq_line_key(first) == "" && !yq_line_is_key) {
+            print k ": " first
+        } else {
+            print k ":"
+            for (i = 1; i <= NR; i++) print (lines[i] == "" ? "" : "  " lines[i])
+        }
+    }
+    ' "$2"
+}
+
+# Variable binding - SRC as $name | BODY and SRC as $name ireduce (INIT; UPDATE)
+# BODY runs once per result of SRC with $name bound to it; ireduce folds the
+# results into an accumulator that starts as INIT and is "." inside UPDATE
+yq_bind() {
+    _bind_query="$1"
+    _bind_file="$2"
+
+    _yq_split_top "$_bind_query" " as \$"
+    _bind_src="$_yq_top_left"
+    _bind_name=$(printf '%s' "$_yq_top_right" | sed 's/^\([a-zA-Z0-9_]*\).*/\1/')
+    _bind_rest=$(printf '%s' "$_yq_top_right" | sed 's/^[a-zA-Z0-9_]*[[:space:]]*//')
+
+    _bind_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$_bind_src" "$_bind_file" > "$_bind_base.src"
+    _bind_count=$(yq_split_results "$_bind_base.src" "$_bind_base.item")
+
+    # Bindings nest, so the outer value is restored afterwards
+    eval "_bind_saved=\${_yq_var_${_bind_name}:-}"
+
+    case "$_bind_rest" in
+        ireduce*)
+            # A pipe after the (INIT; UPDATE) group applies to the final result
+            _bind_args="${_bind_rest#ireduce}"
+            _bind_then="."
+            if _yq_split_top "$_bind_args" " | "; then
+                _bind_args="$_yq_top_left"
+                _bind_then="$_yq_top_right"
+            fi
+            _bind_args=$(printf '%s' "$_bind_args" | sed 's/^[[:space:]]*(//; s/)[[:space:]]*$//')
+            _yq_split_top "$_bind_args" ";"
+            _bind_update="$_yq_top_right"
+            _yq_parse_result "$_yq_top_left" "$_bind_file" > "$_bind_base.acc"
+
+            _bind_i=1
+            while [ "$_bind_i" -le "$_bind_count" ] && ! _yq_raised; do
+                eval "_yq_var_${_bind_name}=\"\$_bind_base.item.\$_bind_i\""
+                _yq_parse_result "$_bind_update" "$_bind_base.acc" > "$_bind_base.next"
+                mv "$_bind_base.next" "$_bind_base.acc"
+                _bind_i=$((_bind_i + 1))
+            done
+            _yq_raised || _yq_parse_result "$_bind_then" "$_bind_base.acc"
+            ;;
+        *)
+            _bind_body=$(printf '%s' "$_bind_rest" | sed 's/^|[[:space:]]*//')
+            _bind_i=1
+            while [ "$_bind_i" -le "$_bind_count" ] && ! _yq_raised; do
+                [ "$_bind_i" -gt 1 ] && echo ""
+                eval "_yq_var_${_bind_name}=\"\$_bind_base.item.\$_bind_i\""
+                _yq_parse_result "$_bind_body" "$_bind_file"
+                _bind_i=$((_bind_i + 1))
+            done
+            ;;
+    esac
+
+    eval "_yq_var_${_bind_name}=\"\$_bind_saved\""
+    rm -f "$_bind_base"*
+}
+
+# Kind of a merge operand, reading the empty flow collections {} and [] too
+_yq_merge_kind() {
+    case "$(tr -d '[:space:]' < "$1")" in
+        "{}") echo "map" ;;
+        "[]") echo "seq" ;;
+        *) yq_node_kind "$1" ;;
+    esac
+}
+
+# Deep merge function - merge the node in the second file into the first (*)
+# Flags: + appends sequences, d merges sequences by index, ? only updates
+# existing keys, n only adds new keys, c lets the right side's tag win
+yq_merge() (
+    _lhs="$1"
+    _rhs="$2"
+    _flags="$3"
+
+    _lkind=$(_yq_merge_kind "$_lhs")
+    _rkind=$(_yq_merge_kind "$_rhs")
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    if [ "$_lkind" = "map" ] && [ "$_rkind" = "map" ]; then
+        : > "$_base.lkeys"
+        : > "$_base.rkeys"
+        [ "$(tr -d '[:space:]' < "$_lhs")" != "{}" ] && yq_map_keys "$_lhs" > "$_base.lkeys" && yq_split_items "$_lhs" "$_base.l" > /dev/null
+        [ "$(tr -d '[:space:]' < "$_rhs")" != "{}" ] && yq_map_keys "$_rhs" > "$_base.rkeys" && yq_split_items "$_rhs" "$_base.r" > /dev/null
+
+        : > "$_base.out"
+        _i=0
+        while IFS= read -r _key || [ -n "$_key" ]; do
+            _i=$((_i + 1))
+            _j=$(grep -nFx -- "$_key" "$_base.rkeys" | head -n 1 | cut -d: -f1)
+            if [ -n "$_j" ]; then
+                yq_merge "$_base.l.$_i" "$_base.r.$_j" "$_flags" > "$_base.value"
+                _yq_object_entry "$_key" "$_base.value" >> "$_base.out"
+            else
+                _yq_object_entry "$_key" "$_base.l.$_i" >> "$_base.out"
+            fi
+        done < "$_base.lkeys"
+
+        if [ "${_flags#*\?}" = "$_flags" ]; then
+            _j=0
+            while IFS= read -r _key || [ -n "$_key" ]; do
+                _j=$((_j + 1))
+                grep -qFx -- "$_key" "$_base.lkeys" && continue
+                _yq_object_entry "$_key" "$_base.r.$_j" >> "$_base.out"
+            done < "$_base.rkeys"
+        fi
+
+        if [ -s "$_base.out" ]; then
+            cat "$_base.out"
+        else
+            echo "{}"
+        fi
+    elif [ "$_lkind" = "seq" ] && [ "$_rkind" = "seq" ] && [ "${_flags#*+}" != "$_flags" ]; then
+        # Append: the items of both sequences one after another
+        _empty=1
+        for _side in "$_lhs" "$_rhs"; do
+            [ "$(tr -d '[:space:]' < "$_side")" = "[]" ] && continue
+            awk '{ print }' "$_side"
+            _empty=0
+        done
+        [ "$_empty" -eq 1 ] && echo "[]"
+    elif [ "$_lkind" = "seq" ] && [ "$_rkind" = "seq" ] && [ "${_flags#*d}" != "$_flags" ]; then
+        # Deep merge items at the same index
+        _lcount=$(yq_split_items "$_lhs" "$_base.l")
+        _rcount=$(yq_split_items "$_rhs" "$_base.r")
+        _count=$_lcount
+        [ "$_rcount" -gt "$_count" ] && _count=$_rcount
+        [ "$_count" -eq 0 ] && echo "[]"
+        _i=1
+        while [ "$_i" -le "$_count" ]; do
+            if [ "$_i" -le "$_lcount" ] && [ "$_i" -le "$_rcount" ]; then
+                yq_merge "$_base.l.$_i" "$_base.r.$_i" "$_flags" > "$_base.value"
+            elif [ "$_i" -le "$_lcount" ]; then
+                cp "$_base.l.$_i" "$_base.value"
+            else
+                cp "$_base.r.$_i" "$_base.value"
+            fi
+            _yq_seq_item "$_base.value"
+            _i=$((_i + 1))
+        done
+    elif [ "${_flags#*n}" != "$_flags" ]; then
+        # Only new keys are merged, existing values stay
+        cat "$_lhs"
+    else
+        # Anything else is replaced by the right side; a custom tag on the
+        # left side is kept unless the c flag is given
+        _ltag=$(head -n 1 "$_lhs" | sed -n 's/^\(![^! ][^ ]*\) .*/\1/p')
+        if [ -n "$_ltag" ] && [ "${_flags#*c}" = "$_flags" ] && [ "$_rkind" = "scalar" ] && ! head -n 1 "$_rhs" | grep -q '^!'; then
+            printf '%s ' "$_ltag"
+        fi
+        cat "$_rhs"
+    fi
+
+    rm -f "$_base"*
+)
+
+# Kind of an operand of + and -: null for null or missing values
+_yq_operand_kind() {
+    case "$(tr -d '[:space:]' < "$1")" in
+        ""|null|"~") echo "null" ;;
+        *) _yq_merge_kind "$1" ;;
+    esac
 }
 
+# Add function - + on collections: arrays concatenate, any other value is
+# appended to an array, maps merge shallowly (the right side replaces whole
+# entries) and null leaves the other side
+yq_add() (
+    _lhs="$1"
+    _rhs="$2"
+
+    _lkind=$(_yq_operand_kind "$_lhs")
+    _rkind=$(_yq_operand_kind "$_rhs")
+
+    if [ "$_lkind" = "null" ]; then
+        cat "$_rhs"
+    elif [ "$_rkind" = "null" ]; then
+        cat "$_lhs"
+    elif [ "$_lkind" = "seq" ] && [ "$_rkind" = "seq" ]; then
+        yq_merge "$_lhs" "$_rhs" "+"
+    elif [ "$_lkind" = "seq" ]; then
+        _base=$(mktemp -p "$_YQ_TEMP_DIR")
+        _yq_seq_item "$_rhs" > "$_base.item"
+        yq_merge "$_lhs" "$_base.item" "+"
+        rm -f "$_base"*
+    elif [ "$_lkind" = "map" ] && [ "$_rkind" = "map" ]; then
+        _base=$(mktemp -p "$_YQ_TEMP_DIR")
+        : > "$_base.lkeys"
+        : > "$_base.rkeys"
+        [ "$(tr -d '[:space:]' < "$_lhs")" != "{}" ] && yq_map_keys "$_lhs" > "$_base.lkeys" && yq_split_items "$_lhs" "$_base.l" > /dev/null
+        [ "$(tr -d '[:space:]' < "$_rhs")" != "{}" ] && yq_map_keys "$_rhs" > "$_base.rkeys" && yq_split_items "$_rhs" "$_base.r" > /dev/null
+
+        : > "$_base.out"
+        _i=0
+        while IFS= read -r _key || [ -n "$_key" ]; do
+            _i=$((_i + 1))
+            _j=$(grep -nFx -- "$_key" "$_base.rkeys" | head -n 1 | cut -d: -f1)
+            if [ -n "$_j" ]; then
+                _yq_object_entry "$_key" "$_base.r.$_j" >> "$_base.out"
+            else
+                _yq_object_entry "$_key" "$_base.l.$_i" >> "$_base.out"
+            fi
+        done < "$_base.lkeys"
+        _j=0
+        while IFS= read -r _key || [ -n "$_key" ]; do
+            _j=$((_j + 1))
+            grep -qFx -- "$_key" "$_base.lkeys" && continue
+            _yq_object_entry "$_key" "$_base.r.$_j" >> "$_base.out"
+        done < "$_base.rkeys"
+
+        if [ -s "$_base.out" ]; then
+            cat "$_base.out"
+        else
+            echo "{}"
+        fi
+        rm -f "$_base"*
+    else
+        _yq_error "!!$_rkind cannot be added to a !!$_lkind"
+    fi
+)
+
+# Subtract function - - on arrays removes every item equal to a right side item
+yq_subtract() (
+    _lhs="$1"
+    _rhs="$2"
+
+    _lkind=$(_yq_operand_kind "$_lhs")
+    _rkind=$(_yq_operand_kind "$_rhs")
+
+    if [ "$_lkind" = "null" ]; then
+        echo "null"
+        return
+    fi
+    if [ "$_lkind" != "seq" ] || { [ "$_rkind" != "seq" ] && [ "$_rkind" != "null" ]; }; then
+        _yq_error "!!$_rkind cannot be subtracted from a !!$_lkind"
+        return
+    fi
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _lcount=$(yq_split_items "$_lhs" "$_base.l")
+    _rcount=0
+    [ "$_rkind" = "seq" ] && _rcount=$(yq_split_items "$_rhs" "$_base.r")
+
+    # Items compare by their value, so "a" and a are the same string
+    : > "$_base.remove"
+    _j=1
+    while [ "$_j" -le "$_rcount" ]; do
+        yq_unquote "$(cat "$_base.r.$_j")" | tr '\n' '\036' >> "$_base.remove"
+        echo >> "$_base.remove"
+        _j=$((_j + 1))
+    done
+
+    _kept=0
+    _i=1
+    while [ "$_i" -le "$_lcount" ]; do
+        _item=$(yq_unquote "$(cat "$_base.l.$_i")" | tr '\n' '\036')
+        if ! grep -qFx -- "$_item" "$_base.remove"; then
+            _yq_seq_item "$_base.l.$_i"
+            _kept=1
+        fi
+        _i=$((_i + 1))
+    done
+    [ "$_kept" -eq 0 ] && echo "[]"
+
+    rm -f "$_base"*
+)
+
+# Truthiness of a result: everything except false and null is true
+# Comparisons print one line per compared value, so every line must be false
+# or null for the result to be false
+_yq_truthy() {
+    grep -qv '^[[:space:]]*\(false\|null\|~\)\{0,1\}[[:space:]]*$' "$1"
+}
+
+# Conditional - if C then T elif C then T else E end
+# Each result of a condition selects a branch for the input; without an
+# else branch a false condition passes the input through unchanged
+yq_if() {
+    _if_parts=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_if_parts "$1" > "$_if_parts"
+    _yq_if_eval "$_if_parts" 1 "$2"
+    _if_status=$?
+    rm -f "$_if_parts"
+    return $_if_status
+}
+
+# Evaluate the if parts starting at line $2 of the parts file $1
+_yq_if_eval() (
+    _parts="$1"
+    _k="$2"
+    _file="$3"
+
+    _line=$(sed -n "${_k}p" "$_parts")
+    case "$_line" in
+        C:*)
+            _then=$(sed -n "$((_k + 1))p" "$_parts")
+            _base=$(mktemp -p "$_YQ_TEMP_DIR")
+            _yq_parse_result "${_line#C:}" "$_file" > "$_base.cond" || return 1
+            _count=$(yq_split_results "$_base.cond" "$_base.result")
+            _i=1
+            while [ "$_i" -le "$_count" ]; do
+                [ "$_i" -gt 1 ] && echo ""
+                if _yq_truthy "$_base.result.$_i"; then
+                    _yq_parse_result "${_then#T:}" "$_file" || return 1
+                else
+                    _yq_if_eval "$_parts" $((_k + 2)) "$_file" || return 1
+                fi
+                _i=$((_i + 1))
+            done
+            rm -f "$_base"*
+            ;;
+        E:*)
+            _yq_parse_result "${_line#E:}" "$_file"
+            ;;
+        *)
+            cat "$_file"
+            ;;
+    esac
+)
+
+# Error function - error("msg") raises the message from an expression
+# evaluated against the input (error on its own uses the input)
+yq_raise() {
+    _raise_msg=$(yq_unquote "$(_yq_parse_result "$1" "$2")")
+    _yq_error "$_raise_msg"
+}
+
+# Try function - try BODY catch HANDLER
+# Errors raised by the body are not printed: without a handler they are
+# dropped, otherwise the handler runs on the error message. Results the
+# body produced before failing are kept
+yq_try() (
+    _body="$1"
+    _handler="$2"
+    _file="$3"
+    _error="$_YQ_TEMP_DIR/.error"
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    # Keep an error raised outside of this try for the exit code
+    [ -f "$_error" ] && mv "$_error" "$_base.outer"
+
+    _yq_parse_result "$_body" "$_file" > "$_base.out" 2>/dev/null
+    cat "$_base.out"
+
+    _status=0
+    if [ -f "$_error" ]; then
+        yq_string_literal "\"$(sed 's/[\\"]/\\&/g' "$_error")\"" > "$_base.msg"
+        rm -f "$_error"
+        if [ -n "$_handler" ]; then
+            [ -s "$_base.out" ] && echo ""
+            _yq_parse_result "$_handler" "$_base.msg"
+            _status=$?
+        fi
+    fi
+
+    [ -f "$_base.outer" ] && mv "$_base.outer" "$_error"
+    rm -f "$_base"*
+    return $_status
+)
+
 # Select function - filter elements based on condition
 yq_select() {
     _sel_expr="$1"
@@ -83,6 +978,12 @@ yq_select() {
     # Evaluate the expression
     _sel_result=$(yq_parse "$_sel_expr" "$_sel_file" 2>/dev/null) || _sel_result=""
 
+    # Only errors raised on purpose are reported, the condition aborts
+    if _yq_raised; then
+        >&2 echo "Error: $(cat "$_YQ_TEMP_DIR/.error")"
+        return 1
+    fi
+
     [ -n "$POSIX_YQ_DEBUG" ] && >&2 echo "DEBUG[select]: result='$_sel_result'"
 
     # Check if result contains any "true" value
@@ -185,55 +1086,31 @@ yq_compare() {
 }
 
 # Recursive descent with pipe - apply expression to each node
-yq_recursive_descent_pipe() {
+yq_recursive_descent_pipe() (
     _rdp_file="$1"
     _rdp_pipe_expr="$2"
 
-    # Process current node through pipe
-    _rdp_tmp_node=$(mktemp -p "$_YQ_TEMP_DIR")
-    cat "$_rdp_file" > "$_rdp_tmp_node"
-    yq_parse "$_rdp_pipe_expr" "$_rdp_tmp_node"
-    rm -f "$_rdp_tmp_node"
-
-    # Recursively descend into all child nodes
-    # Check if it'\''s an object (has keys with colons)
-    if grep -q '^[a-zA-Z_][a-zA-Z0-9_]*:' "$_rdp_file" 2>/dev/null; then
-        # For each TOP-LEVEL key in the object (indent = 0)
-        _rdp_tmp_keys=$(mktemp -p "$_YQ_TEMP_DIR")
-        awk '/^[a-zA-Z_][a-zA-Z0-9_]*:/ {
-            match($0, /^[a-zA-Z_][a-zA-Z0-9_]*/)
-            print substr($0, RSTART, RLENGTH)
-        }' "$_rdp_file" > "$_rdp_tmp_keys" 2>/dev/null
-
-        # Read all keys into a space-separated list to avoid fd conflicts
-        _rdp_keys_list=$(cat "$_rdp_tmp_keys" | tr '\n' ' ')
-        rm -f "$_rdp_tmp_keys"
-
-        # Process each key
-        for _rdp_key in $_rdp_keys_list; do
-            _rdp_tmp_child=$(mktemp -p "$_YQ_TEMP_DIR")
-            yq_key_access "$_rdp_key" "$_rdp_file" > "$_rdp_tmp_child" 2>/dev/null
-            if [ -s "$_rdp_tmp_child" ]; then
-                yq_recursive_descent_pipe "$_rdp_tmp_child" "$_rdp_pipe_expr"
-            fi
-            rm -f "$_rdp_tmp_child"
-        done
-    # Check if it'\''s an array
-    elif grep -q '^-' "$_rdp_file" 2>/dev/null; then
-        # For each element in the array
-        _rdp_tmp_arr=$(mktemp -p "$_YQ_TEMP_DIR")
-        grep '^-' "$_rdp_file" > "$_rdp_tmp_arr"
-
-        while IFS= read -r _rdp_line || [ -n "$_rdp_line" ]; do
-            _rdp_value=$(echo "$_rdp_line" | sed 's/^- //')
-            _rdp_tmp_elem=$(mktemp -p "$_YQ_TEMP_DIR")
-            echo "$_rdp_value" > "$_rdp_tmp_elem"
-            yq_recursive_descent_pipe "$_rdp_tmp_elem" "$_rdp_pipe_expr"
-            rm -f "$_rdp_tmp_elem"
-        done < "$_rdp_tmp_arr"
-        rm -f "$_rdp_tmp_arr"
-    fi
-}
+    # Visit the root, then every node below it in document order, each in
+    # its own context so key, parent and line refer to where it sits
+    _rdp_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    echo "." > "$_rdp_base.paths"
+    _yq_all_paths "$_rdp_file" "" >> "$_rdp_base.paths"
+    _rdp_ctx="$_yq_ctx"
+    _rdp_first=1
+    _rdp_status=0
+    while IFS= read -r _rdp_path || [ -n "$_rdp_path" ]; do
+        _yq_node_at "$_rdp_path" "$_rdp_file" > "$_rdp_base.node"
+        _yq_ctx=$(_yq_ctx_join "$_rdp_ctx" "$_rdp_path")
+        _yq_parse_result "$_rdp_pipe_expr" "$_rdp_base.node" > "$_rdp_base.out" || _rdp_status=1
+        if [ -s "$_rdp_base.out" ]; then
+            [ "$_rdp_first" -eq 0 ] && echo ""
+            cat "$_rdp_base.out"
+            _rdp_first=0
+        fi
+    done < "$_rdp_base.paths"
+    rm -f "$_rdp_base"*
+    return $_rdp_status
+)
 
 # Recursive descent - output all nodes in tree
 yq_recursive_descent() {
@@ -292,82 +1169,50 @@ yq_recursive_descent() {
     fi
 }
 
-# Arithmetic operations - handles +, -, *, / operators
+# Arithmetic operations - + - * / % on two scalar operand files
+# Integers are computed digit by digit, so ids beyond 2^53 stay exact; floats
+# use awk doubles printed in their shortest form. + also concatenates strings
+# and treats null as the identity
 yq_arithmetic() {
-    _expr="$1"
-    _file="$2"
+    _arith_op="$1"
+    _arith_l=$(sed -e 's/^[[:space:]]*//' -e 's/[[:space:]]*$//' "$2")
+    _arith_r=$(sed -e 's/^[[:space:]]*//' -e 's/[[:space:]]*$//' "$3")
 
-    # Determine operator
-    _operator=""
-    _left=""
-    _right=""
-
-    if echo "$_expr" | grep -q ' + '; then
-        _operator="+"
-        _left=$(echo "$_expr" | sed 's/ +.*//')
-        _right=$(echo "$_expr" | sed 's/.* + //')
-    elif echo "$_expr" | grep -q ' - '; then
-        _operator="-"
-        _left=$(echo "$_expr" | sed 's/ -.*//')
-        _right=$(echo "$_expr" | sed 's/.* - //')
-    elif echo "$_expr" | grep -q ' \* '; then
-        _operator="*"
-        _left=$(echo "$_expr" | sed 's/ \*.*//')
-        _right=$(echo "$_expr" | sed 's/.* \* //')
-    elif echo "$_expr" | grep -q ' / '; then
-        _operator="/"
-        _left=$(echo "$_expr" | sed 's/ \/.*//')
-        _right=$(echo "$_expr" | sed 's/.* \/ //')
-    else
-        echo "null"
+    _arith_num='^[-+]\{0,1\}\([0-9][0-9_]*\(\.[0-9]*\)\{0,1\}\|\.[0-9][0-9]*\)\([eE][-+]\{0,1\}[0-9][0-9]*\)\{0,1\}$'
+    if printf '%s\n' "$_arith_l" | grep -q "$_arith_num" && printf '%s\n' "$_arith_r" | grep -q "$_arith_num"; then
+        awk -v op="$_arith_op" -v l="$_arith_l" -v r="$_arith_r" '` + awkArithmetic + `
+        BEGIN {
+            result = yq_calc(op, l, r)
+            if (result == "") exit 1
+            print result
+        }
+        ' || _yq_error "cannot divide by zero"
         return
     fi
 
-    # Evaluate both sides
-    _tmp_left=$(mktemp -p "$_YQ_TEMP_DIR")
-    _tmp_right=$(mktemp -p "$_YQ_TEMP_DIR")
-
-    yq_parse "$_left" "$_file" > "$_tmp_left" 2>/dev/null || true
-
-    # For right side, check if it's a literal number or expression
-    if echo "$_right" | grep -q '^[0-9]\+$'; then
-        echo "$_right" > "$_tmp_right"
-    else
-        yq_parse "$_right" "$_file" > "$_tmp_right" 2>/dev/null || true
-    fi
-
-    # Read left and right values
-    _left_val=$(cat "$_tmp_left" 2>/dev/null)
-    _right_val=$(cat "$_tmp_right" 2>/dev/null)
-
-    # Check if both are numeric
-    if echo "$_left_val" | grep -q '^-\?[0-9]\+\(\\.[0-9]\+\)\?$' && \
-       echo "$_right_val" | grep -q '^-\?[0-9]\+\(\\.[0-9]\+\)\?$'; then
-        # Numeric operation - use awk to avoid POSIX shell arithmetic issues
-        case "$_operator" in
-            "+")
-                echo "$_left_val" | awk -v r="$_right_val" '{printf "%d", $1 + r}'
-                ;;
-            "-")
-                echo "$_left_val" | awk -v r="$_right_val" '{printf "%d", $1 - r}'
-                ;;
-            "*")
-                echo "$_left_val" | awk -v r="$_right_val" '{printf "%d", $1 * r}'
-                ;;
-            "/")
-                echo "$_left_val" | awk -v r="$_right_val" '{printf "%d", int($1 / r)}'
-                ;;
-        esac
-    else
-        # String concatenation (only for +)
-        if [ "$_operator" = "+" ]; then
-            printf '%s%s' "$_left_val" "$_right_val"
-        else
-            echo "null"
-        fi
-    fi
+    # Times move by durations: .deployed + "1h30m"
+    case "$_arith_op" in
+        "+"|"-")
+            yq_datetime_shift "$_arith_op" "$2" "$3"
+            _arith_shift=$?
+            [ "$_arith_shift" -ne 2 ] && return $_arith_shift
+            ;;
+    esac
 
-    rm -f "$_tmp_left" "$_tmp_right"
+    case "$_arith_op" in
+        "+")
+            case "$_arith_l" in ""|null|"~") printf '%s\n' "$_arith_r"; return ;; esac
+            case "$_arith_r" in ""|null|"~") printf '%s\n' "$_arith_l"; return ;; esac
+            # String concatenation, the joined text is quoted again as needed
+            _arith_text=$(mktemp -p "$_YQ_TEMP_DIR")
+            { yq_scalar_text "$2"; yq_scalar_text "$3"; } > "$_arith_text"
+            yq_string_scalar "$_arith_text"
+            rm -f "$_arith_text"
+            ;;
+        *)
+            _yq_error "cannot apply $_arith_op to $_arith_l and $_arith_r"
+            ;;
+    esac
 }
 `
 }
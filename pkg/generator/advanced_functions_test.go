@@ -16,6 +16,7 @@
 package generator
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -97,8 +98,11 @@ func TestYqSelect(t *testing.T) {
 }
 
 func TestYqMap(t *testing.T) {
-	code := GenerateAdvancedFunctions()
-	tester := NewShellFunctionTester(t, code)
+	// yq_map splits elements with yq_split_items
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+	)
 	defer tester.Cleanup()
 
 	t.Run("map arithmetic", func(t *testing.T) {
@@ -127,3 +131,163 @@ func TestYqKeys(t *testing.T) {
 		}
 	})
 }
+
+func TestYqSort(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+	)
+	defer tester.Cleanup()
+
+	t.Run("numbers before strings", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "- b\n- 10\n- \"a\"\n- 9\n- -1")
+		tester.ExecuteFunctionExpect("- -1\n- 9\n- 10\n- \"a\"\n- b", "yq_sort", testFile)
+	})
+}
+
+func TestYqJoin(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+	)
+	defer tester.Cleanup()
+
+	t.Run("unquoted items", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "- a\n- \"b c\"\n- 3")
+		tester.ExecuteFunctionExpect("a-b c-3", "yq_join", "-", testFile)
+	})
+}
+
+func TestYqMerge(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+	)
+	defer tester.Cleanup()
+
+	lhs := "a: 1\nm:\n  x: 1\n  s:\n    - p\nt: !tag old"
+	rhs := "b: 2\nm:\n  y: 2\n  s:\n    - q\nt: new"
+
+	tests := []struct {
+		name     string
+		flags    string
+		expected string
+	}{
+		{name: "deep merge", flags: "", expected: "a: 1\nm:\n  x: 1\n  s:\n    - q\n  y: 2\nt: !tag new\nb: 2"},
+		{name: "append arrays", flags: "+", expected: "a: 1\nm:\n  x: 1\n  s:\n    - p\n    - q\n  y: 2\nt: !tag new\nb: 2"},
+		{name: "existing keys only", flags: "?", expected: "a: 1\nm:\n  x: 1\n  s:\n    - q\nt: !tag new"},
+		{name: "new keys only", flags: "n", expected: "a: 1\nm:\n  x: 1\n  s:\n    - p\n  y: 2\nt: !tag old\nb: 2"},
+		{name: "clobber tags", flags: "c", expected: "a: 1\nm:\n  x: 1\n  s:\n    - q\n  y: 2\nt: new\nb: 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lhsFile := tester.WriteFile("lhs.yaml", lhs)
+			rhsFile := tester.WriteFile("rhs.yaml", rhs)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_merge", lhsFile, rhsFile, tt.flags)
+		})
+	}
+
+	t.Run("arrays by index", func(t *testing.T) {
+		lhsFile := tester.WriteFile("lhs.yaml", "- a: 1\n- a: 2")
+		rhsFile := tester.WriteFile("rhs.yaml", "- b: 3")
+		tester.ExecuteFunctionExpect("- a: 1\n  b: 3\n- a: 2", "yq_merge", lhsFile, rhsFile, "d")
+	})
+
+	t.Run("empty left side", func(t *testing.T) {
+		lhsFile := tester.WriteFile("lhs.yaml", "{}")
+		rhsFile := tester.WriteFile("rhs.yaml", "a: 1")
+		tester.ExecuteFunctionExpect("a: 1", "yq_merge", lhsFile, rhsFile, "")
+	})
+}
+
+func TestYqAddSubtract(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+	)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		function string
+		lhs      string
+		rhs      string
+		expected string
+	}{
+		{name: "concatenate arrays", function: "yq_add", lhs: "- a\n- b", rhs: "- c", expected: "- a\n- b\n- c"},
+		{name: "add to null", function: "yq_add", lhs: "null", rhs: "- c", expected: "- c"},
+		{name: "append scalar", function: "yq_add", lhs: "- a\n- b", rhs: "git", expected: "- a\n- b\n- git"},
+		{name: "append map", function: "yq_add", lhs: "- a", rhs: "x: 1\ny: 2", expected: "- a\n- x: 1\n  y: 2"},
+		{name: "shallow map merge", function: "yq_add", lhs: "a:\n  x: 1\nb: 2", rhs: "a:\n  y: 2\nc: 3", expected: "a:\n  y: 2\nb: 2\nc: 3"},
+		{name: "array difference", function: "yq_subtract", lhs: "- a\n- \"b\"\n- c\n- b", rhs: "- b", expected: "- a\n- c"},
+		{name: "remove everything", function: "yq_subtract", lhs: "- a", rhs: "- a", expected: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lhsFile := tester.WriteFile("lhs.yaml", tt.lhs)
+			rhsFile := tester.WriteFile("rhs.yaml", tt.rhs)
+			tester.ExecuteFunctionExpect(tt.expected, tt.function, lhsFile, rhsFile)
+		})
+	}
+
+	t.Run("map plus array fails", func(t *testing.T) {
+		lhsFile := tester.WriteFile("lhs.yaml", "a: 1")
+		rhsFile := tester.WriteFile("rhs.yaml", "- a")
+		tester.ExecuteFunctionExpectError("yq_add", lhsFile, rhsFile)
+	})
+
+	t.Run("error names the right side first", func(t *testing.T) {
+		lhsFile := tester.WriteFile("lhs.yaml", "a: 1")
+		rhsFile := tester.WriteFile("rhs.yaml", "- a")
+		output, _ := tester.ExecuteFunction("yq_add", lhsFile, rhsFile)
+		if !strings.Contains(output, "!!seq cannot be added to a !!map") {
+			t.Errorf("unexpected error message %q", output)
+		}
+	})
+}
+
+func TestYqArithmetic(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateDatetime(),
+	)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		op       string
+		lhs      string
+		rhs      string
+		expected string
+	}{
+		{name: "float product", op: "*", lhs: "1.5", rhs: "2", expected: "3"},
+		{name: "float division", op: "/", lhs: "10", rhs: "4", expected: "2.5"},
+		{name: "exact division", op: "/", lhs: "4", rhs: "2", expected: "2"},
+		{name: "shortest float", op: "+", lhs: "0.1", rhs: "0.2", expected: "0.30000000000000004"},
+		{name: "modulo", op: "%", lhs: "-7", rhs: "3", expected: "-1"},
+		{name: "big integer sum", op: "+", lhs: "9007199254740993", rhs: "1", expected: "9007199254740994"},
+		{name: "big integer product", op: "*", lhs: "123456789012345678901", rhs: "-3", expected: "-370370367037037036703"},
+		{name: "big integer division", op: "/", lhs: "18446744073709551616", rhs: "4", expected: "4611686018427387904"},
+		{name: "big integer modulo", op: "%", lhs: "18446744073709551617", rhs: "10", expected: "7"},
+		{name: "string concatenation", op: "+", lhs: "\"a\"", rhs: "b", expected: "ab"},
+		{name: "null identity", op: "+", lhs: "null", rhs: "2", expected: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lhsFile := tester.WriteFile("lhs.yaml", tt.lhs)
+			rhsFile := tester.WriteFile("rhs.yaml", tt.rhs)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_arithmetic", tt.op, lhsFile, rhsFile)
+		})
+	}
+
+	t.Run("division by zero fails", func(t *testing.T) {
+		lhsFile := tester.WriteFile("lhs.yaml", "1")
+		rhsFile := tester.WriteFile("rhs.yaml", "0")
+		tester.ExecuteFunctionExpectError("yq_arithmetic", "/", lhsFile, rhsFile)
+	})
+}
@@ -15,6 +15,168 @@
 
 package generator
 
+// awkBlockScalarDecode is the awk function that turns the body of a literal
+// (|) or folded (>) block scalar into its string value. It is spliced into
+// every awk program that has to read block scalars so folding and chomping
+// behave the same everywhere.
+const awkBlockScalarDecode = `
+    # Decode block scalar body lines[1..n] (block indentation already removed)
+    # style is "|" or ">", chomp is "-" (strip), "+" (keep) or "" (clip)
+    function yq_block_decode(lines, n, style, chomp,    out, i, k, trail, empties, started, prev, type, sep) {
+        # Trailing empty lines only matter for keep chomping
+        trail = 0
+        while (n > 0 && lines[n] == "") {
+            n--
+            trail++
+        }
+        out = ""
+        started = 0
+        empties = 0
+        prev = ""
+        for (i = 1; i <= n; i++) {
+            if (style == "|") {
+                out = (i == 1) ? lines[i] : out "\n" lines[i]
+                continue
+            }
+            # Folded: empty lines are counted and emitted with the next line
+            if (lines[i] == "") {
+                empties++
+                continue
+            }
+            type = (lines[i] ~ /^[ \t]/) ? "more" : "normal"
+            sep = ""
+            if (!started) {
+                for (k = 0; k < empties; k++) sep = sep "\n"
+                started = 1
+            } else if (prev == "normal" && type == "normal") {
+                # Line break between two normal lines folds into a space
+                if (empties == 0) sep = " "
+                for (k = 0; k < empties; k++) sep = sep "\n"
+            } else {
+                # More-indented lines keep their line breaks
+                sep = "\n"
+                for (k = 0; k < empties; k++) sep = sep "\n"
+            }
+            out = out sep lines[i]
+            empties = 0
+            prev = type
+        }
+        if (n > 0 && chomp != "-") out = out "\n"
+        if (chomp == "+") {
+            for (k = 0; k < trail; k++) out = out "\n"
+        }
+        return out
+    }
+`
+
+// awkMapKey is the awk function that recognises a block mapping entry and
+// returns its key. Callers compare the key for equality and check the line
+// indentation themselves, so lookups never match keys of nested mappings.
+const awkMapKey = `
+    # Return the key of a "key: value" line ("" if the line is not an entry)
+    # The text after the colon is left in yq_line_value and yq_line_is_key
+    # tells an empty key ("": 1) from a line that is not an entry
+    function yq_line_key(line,    key, rest, pos) {
+        yq_line_value = ""
+        yq_line_is_key = 0
+        sub(/^ */, "", line)
+        if (line ~ /^"/ && match(line, /^"([^"\\]|\\.)*"[[:space:]]*:/)) {
+            # Double-quoted key
+            rest = substr(line, RLENGTH + 1)
+            key = substr(line, 2, RLENGTH - 1)
+            sub(/"[[:space:]]*:$/, "", key)
+        } else if (line ~ /^'"'"'/ && match(line, /^'"'"'[^'"'"']*'"'"'[[:space:]]*:/)) {
+            # Single-quoted key
+            rest = substr(line, RLENGTH + 1)
+            key = substr(line, 2, RLENGTH - 1)
+            sub(/'"'"'[[:space:]]*:$/, "", key)
+        } else if (line ~ /^["'"'"'[{]/) {
+            # Quoted scalar or flow collection, not a key
+            return ""
+        } else if (line ~ /^[^-#"'"'"' ][^:]*:$/ || line ~ /^-[^ ][^:]*:$/) {
+            # Plain key with nothing after the colon
+            yq_line_is_key = 1
+            return substr(line, 1, length(line) - 1)
+        } else {
+            # Plain key: everything before the first ": "
+            pos = index(line, ": ")
+            if (pos == 0 || line ~ /^(#|- |-$)/) return ""
+            key = substr(line, 1, pos - 1)
+            rest = substr(line, pos + 1)
+        }
+        if (rest != "" && rest !~ /^[ \t]/) return ""
+        sub(/^[ \t]+/, "", rest)
+        yq_line_value = rest
+        yq_line_is_key = 1
+        return key
+    }
+`
+
+// awkScalarQuote is the awk function that writes a string as a single-line
+// YAML scalar. The plain form is kept only when it reads back as the same
+// string; anything that would resolve to a number, bool or null, or that
+// holds indicators, is double-quoted with its escapes.
+const awkScalarQuote = `
+    function yq_quote(text) {
+        if (text == "" || text ~ /^[-?:,\[\]{}#&*!|>'"'"'"%@` + "`" + ` ]/ || text ~ /[ \t]$/ ||
+            text ~ /: |:$| #|[\t\n\r\\"]/ || text ~ /[^ -~\200-\377]/ ||
+            text ~ /^(true|True|TRUE|false|False|FALSE|null|Null|NULL|~)$/ ||
+            text ~ /^[-+]?([0-9][0-9_]*(\.[0-9]*)?([eE][-+]?[0-9]+)?|\.[0-9]+([eE][-+]?[0-9]+)?)$/ ||
+            text ~ /^(0x[0-9a-fA-F_]+|0o[0-7_]+|0b[01_]+|[-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$/) {
+            gsub(/\\/, "\\\\\\\\", text)
+            gsub(/"/, "\\\"", text)
+            gsub(/\t/, "\\t", text)
+            gsub(/\r/, "\\r", text)
+            gsub(/\n/, "\\n", text)
+            return "\"" text "\""
+        }
+        return text
+    }
+`
+
+// awkUTF8 holds the awk functions that encode a unicode code point as UTF-8
+// and decode double-quoted string escapes, shared by query strings, quoted
+// scalars and JSON input.
+const awkUTF8 = `
+    function yq_utf8(cp) {
+        if (cp < 128) return sprintf("%c", cp)
+        if (cp < 2048) return sprintf("%c%c", 192 + int(cp / 64), 128 + cp % 64)
+        if (cp < 65536) return sprintf("%c%c%c", 224 + int(cp / 4096), 128 + int(cp / 64) % 64, 128 + cp % 64)
+        return sprintf("%c%c%c%c", 240 + int(cp / 262144), 128 + int(cp / 4096) % 64, 128 + int(cp / 64) % 64, 128 + cp % 64)
+    }
+    function yq_hex(s,    i, v) {
+        v = 0
+        for (i = 1; i <= length(s); i++) v = v * 16 + index("0123456789abcdef", tolower(substr(s, i, 1))) - 1
+        return v
+    }
+    # Decode the escapes of a double-quoted string body
+    function yq_unescape(s,    out, i, c, e, cp) {
+        out = ""
+        for (i = 1; i <= length(s); i++) {
+            c = substr(s, i, 1)
+            if (c != "\\") {
+                out = out c
+                continue
+            }
+            e = substr(s, ++i, 1)
+            if (e == "n") out = out "\n"
+            else if (e == "t") out = out "\t"
+            else if (e == "r") out = out "\r"
+            else if (e == "u" && substr(s, i + 1, 4) ~ /^[0-9a-fA-F][0-9a-fA-F][0-9a-fA-F][0-9a-fA-F]$/) {
+                cp = yq_hex(substr(s, i + 1, 4))
+                i += 4
+                if (cp >= 55296 && cp < 56320 && substr(s, i + 1, 2) == "\\u") {
+                    cp = 65536 + (cp - 55296) * 1024 + (yq_hex(substr(s, i + 3, 4)) - 56320)
+                    i += 6
+                }
+                out = out yq_utf8(cp)
+            }
+            else out = out e
+        }
+        return out
+    }
+`
+
 // GenerateCoreFunctions returns core YAML manipulation functions
 func GenerateCoreFunctions() string {
 	return `
@@ -23,7 +185,7 @@ yq_unquote() {
     _value="$1"
     # Don't unquote null or other special values
     if [ "$_value" = "null" ] || [ "$_value" = "true" ] || [ "$_value" = "false" ]; then
-        echo "$_value"
+        printf '%s\n' "$_value"
         return
     fi
     # Check if the value is a quoted string
@@ -33,19 +195,25 @@ yq_unquote() {
         _trimmed="${_trimmed#\"}"
         # Make sure we actually had quotes at both ends
         if [ "${#_value}" -gt 2 ] && [ "${_value%\"}" != "$_value" ]; then
-            _value="$_trimmed"
-            # Unescape special characters
-            _value=$(echo "$_value" | sed 's/\\"/"/g; s/\\\\/\\/g')
+            # Decode the escapes (\", \\, \n, \t, \uXXXX)
+            _value=$(printf '%s\n' "$_trimmed" | LC_ALL=C awk '` + awkUTF8 + `
+            {
+                v = (NR == 1) ? $0 : v "\n" $0
+            }
+            END {
+                printf "%s", yq_unescape(v)
+            }
+            ')
         fi
     elif [ "${_value#\'}" != "$_value" ]; then
         # Remove single quotes if they surround the entire value
         _trimmed="${_value%\'}"
         _trimmed="${_trimmed#\'}"
         if [ "${#_value}" -gt 2 ] && [ "${_value%\'}" != "$_value" ]; then
-            _value="$_trimmed"
+            _value=$(printf '%s\n' "$_trimmed" | sed "s/''/'/g")
         fi
     fi
-    echo "$_value"
+    printf '%s\n' "$_value"
 }
 
 # Extract value for a key
@@ -53,12 +221,50 @@ yq_key_access() {
     _key="$1"
     _file="$2"
 
-    awk -v key="$_key" '
+    awk -v key="$_key" '` + awkMapKey + `
+    # Re-emit a block scalar with its body re-indented to two spaces so the
+    # value can be decoded later without knowing where it came from
+    function flush_scalar(    i, first, ind, header, line) {
+        if (scalar_ind > 0) {
+            ind = key_indent + scalar_ind
+        } else {
+            ind = -1
+            for (i = 1; i <= scalar_n; i++) {
+                if (scalar_lines[i] !~ /^[[:space:]]*$/) {
+                    match(scalar_lines[i], /^ */)
+                    ind = RLENGTH
+                    break
+                }
+            }
+        }
+        header = scalar_style
+        first = 1
+        for (i = 1; i <= scalar_n; i++) {
+            if (scalar_lines[i] ~ /^[[:space:]]*$/) continue
+            # An explicit indentation indicator is needed when the first
+            # line starts with spaces that belong to the content
+            if (first && substr(scalar_lines[i], ind + 1, 1) == " ") header = header "2"
+            first = 0
+        }
+        print header scalar_chomp
+        for (i = 1; i <= scalar_n; i++) {
+            line = scalar_lines[i]
+            if (line ~ /^[[:space:]]*$/ && length(line) <= ind) {
+                print ""
+            } else {
+                print "  " substr(line, ind + 1)
+            }
+        }
+        in_scalar = 0
+    }
     BEGIN {
         found = 0
         key_indent = -1
         block_indent = -1
         in_block = 0
+        in_scalar = 0
+        map_indent = -1
+        same_indent_seq = 0
     }
     {
         # Calculate indentation
@@ -71,11 +277,30 @@ yq_key_access() {
             }
         }
 
+        # The first entry fixes the indentation of the mapping being searched
+        if (map_indent == -1 && $0 !~ /^[[:space:]]*(#.*)?$/ && $0 !~ /^(---|\.\.\.)/) {
+            map_indent = current_indent
+        }
+
+        if (in_scalar) {
+            # Collect block scalar body until a line at or below the key indent
+            if (current_indent > key_indent || $0 ~ /^[[:space:]]*$/) {
+                scalar_lines[++scalar_n] = $0
+                next
+            }
+            flush_scalar()
+            exit
+        }
+
         if (found && in_block) {
             # We are printing the block
             if (block_indent == -1) {
                 # First line after the key
-                if (current_indent > key_indent || $0 ~ /^[[:space:]]*$/) {
+                # A block sequence may sit at the same indentation as its key
+                if (current_indent == key_indent && $0 ~ /^[[:space:]]*-( |$)/) {
+                    same_indent_seq = 1
+                }
+                if (current_indent > key_indent || same_indent_seq || $0 ~ /^[[:space:]]*$/) {
                     if ($0 !~ /^[[:space:]]*$/) {
                         block_indent = current_indent
                         # Remove the block indentation
@@ -87,6 +312,10 @@ yq_key_access() {
                 }
             } else {
                 # Subsequent lines
+                # In a same-indentation sequence only "- " lines stay at the key level
+                if (same_indent_seq && current_indent == block_indent && $0 !~ /^[[:space:]]*-( |$)/) {
+                    exit
+                }
                 if (current_indent >= block_indent || $0 ~ /^[[:space:]]*$/) {
                     # Remove the block indentation
                     if ($0 !~ /^[[:space:]]*$/) {
@@ -97,15 +326,30 @@ yq_key_access() {
                     exit
                 }
             }
-        } else if ($0 ~ "^[[:space:]]*" key ":") {
-            # Found the key
+        } else if (current_indent == map_indent && yq_line_key($0) == key) {
+            # Found the key at the mapping level (nested keys are never matched)
             found = 1
             key_indent = current_indent
 
             # Check if value is on same line
-            if ($0 ~ "^[[:space:]]*" key ": ") {
+            if (yq_line_value != "") {
                 # Inline value
-                sub("^[[:space:]]*" key ": ", "")
+                $0 = yq_line_value
+                # Block scalar header: | or > with optional indicators
+                if ($0 ~ /^[|>][-+0-9]*[[:space:]]*(#.*)?$/) {
+                    sub(/[[:space:]]*(#.*)?$/, "")
+                    in_scalar = 1
+                    scalar_n = 0
+                    scalar_style = substr($0, 1, 1)
+                    scalar_chomp = ""
+                    scalar_ind = 0
+                    for (i = 2; i <= length($0); i++) {
+                        c = substr($0, i, 1)
+                        if (c == "-" || c == "+") scalar_chomp = c
+                        else scalar_ind = c + 0
+                    }
+                    next
+                }
                 print
                 exit
             } else {
@@ -115,6 +359,9 @@ yq_key_access() {
         }
     }
     END {
+        if (in_scalar) {
+            flush_scalar()
+        }
         # If key was never found, print null
         if (!found) {
             print "null"
@@ -123,78 +370,467 @@ yq_key_access() {
     ' "$_file"
 }
 
-# Iterate over array or object elements
-yq_iterate() {
-    _file="$1"
-
-    # Try as array first
-    if head -n 1 "$_file" | grep -q '^-'; then
-        # Array iteration - handle multi-line array elements
-        awk '
-        BEGIN {
-            in_item = 0
-            item_indent = -1
+# Decode a block scalar (| or > header followed by indented body lines)
+# into its string value, applying folding and chomping indicators
+# Reads from the given file, or stdin when no file is given
+yq_block_scalar_decode() {
+    awk '` + awkBlockScalarDecode + `
+    NR == 1 {
+        header = $0
+        sub(/^[[:space:]]*/, "", header)
+        sub(/[[:space:]]*(#.*)?$/, "", header)
+        style = substr(header, 1, 1)
+        chomp = ""
+        ind = 0
+        for (i = 2; i <= length(header); i++) {
+            c = substr(header, i, 1)
+            if (c == "-" || c == "+") chomp = c
+            else ind = c + 0
         }
-        /^-/ {
-            # New array item
-            if (in_item) {
-                # Print TWO newlines before new item to create blank line separator
-                printf "\n\n"
+        n = 0
+        next
+    }
+    {
+        raw[++n] = $0
+    }
+    END {
+        # Without an indentation indicator the first non-empty line decides
+        if (ind == 0) {
+            for (i = 1; i <= n; i++) {
+                if (raw[i] !~ /^[[:space:]]*$/) {
+                    match(raw[i], /^ */)
+                    ind = RLENGTH
+                    break
+                }
             }
-            in_item = 1
-            # Remove "- " prefix and print
-            sub(/^- /, "")
-            # If line has content after "- ", print it
-            if (length($0) > 0) {
-                printf "%s", $0
-                item_indent = 2  # Content started on same line
+        }
+        for (i = 1; i <= n; i++) {
+            if (raw[i] ~ /^[[:space:]]*$/ && length(raw[i]) <= ind) {
+                lines[i] = ""
             } else {
-                item_indent = -1  # Content on next line
+                lines[i] = substr(raw[i], ind + 1)
             }
-            next
         }
-        in_item && /^[[:space:]]/ {
-            # Continuation of array item (indented line)
-            # Calculate indentation
-            indent = 0
-            for (i = 1; i <= length($0); i++) {
-                if (substr($0, i, 1) == " ") {
-                    indent++
-                } else {
-                    break
+        printf "%s", yq_block_decode(lines, n, style, chomp)
+    }
+    ' "${1:--}"
+}
+
+# Print the string value of a scalar node: quotes are removed and block
+# scalars are decoded, so the output is exactly the text of the value
+yq_scalar_text() {
+    if awk '/^[[:space:]]*(#.*)?$/ { next } { exit !($0 ~ /^[|>][-+0-9]*[[:space:]]*(#.*)?$/) }' "$1"; then
+        yq_block_scalar_decode "$1"
+    else
+        LC_ALL=C awk '` + awkUTF8 + `
+        {
+            v = (NR == 1) ? $0 : v "\n" $0
+        }
+        END {
+            sub(/^[ \t\n]+/, "", v)
+            sub(/[ \t\n]+$/, "", v)
+            if (v ~ /^".*"$/ && length(v) > 1) {
+                v = yq_unescape(substr(v, 2, length(v) - 2))
+            } else if (v ~ /^'"'"'.*'"'"'$/ && length(v) > 1) {
+                v = substr(v, 2, length(v) - 2)
+                gsub(/'"'"''"'"'/, "'"'"'", v)
+            }
+            printf "%s", v
+        }
+        ' "$1"
+    fi
+}
+
+# Print the text of a file as a YAML string scalar: plain when it reads back
+# as the same string, double-quoted when it needs escaping and a literal
+# block scalar when it spans several lines
+yq_string_scalar() {
+    _str_trail=0
+    [ -s "$1" ] && [ -z "$(tail -c 1 "$1")" ] && _str_trail=1
+    awk -v trail="$_str_trail" '` + awkScalarQuote + `
+    {
+        lines[++n] = $0
+    }
+    END {
+        text = ""
+        for (i = 1; i <= n; i++) text = (i == 1) ? lines[i] : text "\n" lines[i]
+        if (trail) text = text "\n"
+        if (index(text, "\n") && text ~ /[^\n]/ && lines[1] !~ /^[ \t]/) {
+            # Multi-line: literal block with its chomping indicator
+            if (!trail) chomp = "-"
+            else if (lines[n] == "") chomp = "+"
+            else chomp = ""
+            print "|" chomp
+            for (i = 1; i <= n; i++) print (lines[i] == "" ? "" : "  " lines[i])
+            exit
+        }
+        print yq_quote(text)
+    }
+    ' "$1"
+}
+
+# Evaluate a double-quoted query string: escapes (\n, \t, \", \\, \uXXXX)
+# are decoded and each \(expression) is replaced with the text of its
+# result, then the string is printed as a YAML scalar. An expression with
+# several results gives one string per combination of results, separated by
+# blank lines, and one without results gives no string
+yq_string_build() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_string_parts "$1" > "$_base.parts"
+    : > "$_base.acc.1"
+    _count=1
+
+    while IFS= read -r _part || [ -n "$_part" ]; do
+        case "$_part" in
+            E*)
+                _yq_parse_result "${_part#E}" "$2" > "$_base.value" || { rm -f "$_base"*; return 1; }
+                _nvals=$(yq_split_results "$_base.value" "$_base.val")
+                _next=0
+                _i=1
+                while [ "$_i" -le "$_count" ]; do
+                    _v=1
+                    while [ "$_v" -le "$_nvals" ]; do
+                        _next=$((_next + 1))
+                        cp "$_base.acc.$_i" "$_base.next.$_next"
+                        if [ "$(yq_node_kind "$_base.val.$_v")" = "scalar" ]; then
+                            yq_scalar_text "$_base.val.$_v" >> "$_base.next.$_next"
+                        else
+                            printf '%s' "$(cat "$_base.val.$_v")" >> "$_base.next.$_next"
+                        fi
+                        _v=$((_v + 1))
+                    done
+                    _i=$((_i + 1))
+                done
+                rm -f "$_base.acc."* "$_base.val."*
+                _i=1
+                while [ "$_i" -le "$_next" ]; do
+                    mv "$_base.next.$_i" "$_base.acc.$_i"
+                    _i=$((_i + 1))
+                done
+                _count=$_next
+                ;;
+            S*)
+                printf '%s\n' "${_part#S}" | LC_ALL=C awk '` + awkUTF8 + `
+                {
+                    printf "%s", yq_unescape($0)
                 }
+                ' > "$_base.s"
+                _i=1
+                while [ "$_i" -le "$_count" ]; do
+                    cat "$_base.s" >> "$_base.acc.$_i"
+                    _i=$((_i + 1))
+                done
+                ;;
+        esac
+    done < "$_base.parts"
+
+    _i=1
+    while [ "$_i" -le "$_count" ]; do
+        [ "$_i" -gt 1 ] && echo ""
+        yq_string_scalar "$_base.acc.$_i"
+        _i=$((_i + 1))
+    done
+    rm -f "$_base"*
+)
+
+# Print a double-quoted query string literal as a YAML scalar
+# The quotes are dropped when the text reads back as the same plain string
+yq_string_literal() {
+    printf '%s\n' "$1" | awk '
+    {
+        q = (NR == 1) ? $0 : q "\n" $0
+    }
+    END {
+        s = substr(q, 2, length(q) - 2)
+        if (s ~ /\\/ || s == "" || s ~ /^[-?:,\[\]{}#&*!|>'"'"'"%@ ]/ || s ~ /[ ]$/ ||
+            s ~ /: |:$| #/ || s ~ /^(true|false|null|~|[-+]?[0-9][0-9_]*(\.[0-9]*)?([eE][-+]?[0-9]+)?|[-+]?\.[0-9]+)$/) {
+            print q
+        } else {
+            print s
+        }
+    }
+    '
+}
+
+# Print the kind of the node in a file: seq, map or scalar
+# Leading blank lines, comments and document markers are skipped
+yq_node_kind() {
+    awk '` + awkMapKey + `
+    /^[[:space:]]*(#.*)?$/ || /^(---|\.\.\.)/ {
+        next
+    }
+    {
+        if ($0 ~ /^[[:space:]]*-( |$)/) kind = "seq"
+        else if (yq_line_key($0) != "" || yq_line_is_key) kind = "map"
+        else kind = "scalar"
+        exit
+    }
+    END {
+        printf "%s", kind == "" ? "scalar" : kind
+    }
+    ' "$1"
+}
+
+# Print the keys of a mapping, one per line, in document order
+yq_map_keys() {
+    awk '` + awkMapKey + `
+    BEGIN {
+        map_indent = -1
+    }
+    /^[[:space:]]*(#.*)?$/ || /^(---|\.\.\.)/ {
+        next
+    }
+    {
+        match($0, /^ */)
+        if (map_indent == -1) map_indent = RLENGTH
+        if (RLENGTH == map_indent) {
+            key = yq_line_key($0)
+            if (yq_line_is_key) print key
+        }
+    }
+    ' "$1"
+}
+
+# Split a stream of results (as printed by iteration and the comma operator)
+# into one file per result (base.1, base.2, ...) and print the count
+# Results are separated by blank lines, but blank lines also appear inside
+# documents, so a blank line only starts a new result when the next line
+# cannot continue the current one: a different kind of node, another scalar
+# or sequence, or a key the current mapping already has. A repeated key
+# starts a new mapping even without a blank line. Blank lines inside block
+# scalar bodies always belong to the value, a node always belongs to the
+# head comments before it and a "---" document separator always ends the
+# current result
+yq_split_results() {
+    awk -v base="$2" '` + awkMapKey + `
+    BEGIN {
+        n = 0
+        kind = ""
+        pending = 0
+        in_scalar = 0
+        new_doc = 0
+    }
+    /^---[[:space:]]*$/ {
+        if (n > 0) new_doc = 1
+        in_scalar = 0
+        pending = 0
+        next
+    }
+    /^[[:space:]]*$/ {
+        if (n > 0) pending++
+        next
+    }
+    {
+        match($0, /^ */)
+        ind = RLENGTH
+
+        # A top-level block scalar with an indented body ends at column 0
+        if (in_scalar && scalar_indent == -1 && ind > 0) scalar_indent = 0
+        if (in_scalar && ind > scalar_indent) {
+            for (; pending > 0; pending--) print "" > out
+            print > out
+            next
+        }
+        in_scalar = 0
+
+        boundary = (n == 0 || new_doc)
+        new_doc = 0
+        key = ""
+        if (ind == 0) {
+            if ($0 ~ /^-( |$)/) line_kind = "seq"
+            else if ((key = yq_line_key($0)) != "" || yq_line_is_key) line_kind = "map"
+            else line_kind = "scalar"
+
+            if (n > 0 && only_comments) {
+                # Head comments belong to the node after them
+            } else if (n > 0 && line_kind == "map" && kind == "map" && (key in seen)) {
+                boundary = 1
+            } else if (n > 0 && pending > 0 && (line_kind != kind || kind != "map")) {
+                boundary = 1
             }
+        }
+
+        if (boundary) {
+            if (n > 0) close(out)
+            n++
+            out = base "." n
+            kind = line_kind
+            only_comments = ($0 ~ /^#/)
+            for (k in seen) delete seen[k]
+            pending = 0
+        } else if (only_comments && $0 !~ /^[[:space:]]*#/) {
+            kind = line_kind
+            only_comments = 0
+        }
+        for (; pending > 0; pending--) print "" > out
+        print > out
+        if (key != "") seen[key] = 1
+
+        if ($0 ~ /^[|>][-+0-9]*[[:space:]]*$/) {
+            in_scalar = 1
+            scalar_indent = -1
+        } else if ($0 ~ /(:|^[[:space:]]*-)[[:space:]]+[|>][-+0-9]*[[:space:]]*(#.*)?$/) {
+            in_scalar = 1
+            scalar_indent = ind
+        }
+    }
+    END {
+        if (n > 0) close(out)
+        printf "%d", n
+    }
+    ' "$1"
+}
 
-            # If this is the first indented line after "- ", set the base indent
-            if (item_indent == -1) {
-                item_indent = indent
+# Split a sequence or mapping into one file per item (base.1, base.2, ...)
+# and print the number of items. Sequence items are de-indented so that
+# nested sequences (- - a) and compact maps (- key: v) become standalone
+# nodes; mapping items are the values of each entry in document order
+yq_split_items() {
+    _split_file="$1"
+    _split_base="$2"
+
+    case "$(yq_node_kind "$_split_file")" in
+        seq)
+            awk -v base="$_split_base" '
+            BEGIN {
+                n = 0
+                seq_indent = -1
+                item_col = -1
+                blanks = 0
+                done = 0
+            }
+            done {
+                next
             }
+            /^[[:space:]]*$/ {
+                # Blank lines are only kept when more item content follows
+                if (n > 0) blanks++
+                next
+            }
+            seq_indent == -1 && (/^[[:space:]]*#/ || /^(---|\.\.\.)/) {
+                next
+            }
+            {
+                match($0, /^ */)
+                ind = RLENGTH
+                if (seq_indent == -1) seq_indent = ind
+                rest = substr($0, ind + 1)
+
+                if (ind == seq_indent && rest ~ /^-( |$)/) {
+                    # Start of a new item
+                    if (n > 0) close(out)
+                    n++
+                    out = base "." n
+                    blanks = 0
+                    content = substr(rest, 2)
+                    match(content, /^ */)
+                    if (RLENGTH == length(content)) {
+                        # Item content starts on the next line
+                        item_col = -1
+                        printf "" > out
+                    } else {
+                        # Continuation lines align with the text after "- "
+                        item_col = ind + 1 + RLENGTH
+                        print substr(content, RLENGTH + 1) > out
+                    }
+                    next
+                }
 
-            # Remove the base indentation
-            if (indent >= item_indent) {
-                for (i = 1; i <= item_indent; i++) {
-                    sub(/^ /, "")
+                if (ind <= seq_indent) {
+                    # Dedent (or a sibling key): the sequence is over
+                    done = 1
+                    next
+                }
+
+                # Continuation of the current item
+                if (item_col == -1) item_col = ind
+                for (; blanks > 0; blanks--) print "" > out
+                if (ind >= item_col) {
+                    print substr($0, item_col + 1) > out
+                } else {
+                    print rest > out
                 }
-                printf "\n%s", $0
             }
-        }
-        ' "$_file"
+            END {
+                if (n > 0) close(out)
+                printf "%d", n
+            }
+            ' "$_split_file"
+            ;;
+        map)
+            _split_keys=$(mktemp -p "$_YQ_TEMP_DIR")
+            yq_map_keys "$_split_file" > "$_split_keys"
 
-        # Add final newline if we printed anything
-        if [ -s "$_file" ]; then
-            printf "\n"
+            _split_n=0
+            while IFS= read -r _split_key || [ -n "$_split_key" ]; do
+                _split_n=$((_split_n + 1))
+                yq_key_access "$_split_key" "$_split_file" > "$_split_base.$_split_n"
+            done < "$_split_keys"
+            rm -f "$_split_keys"
+            printf "%d" "$_split_n"
+            ;;
+        *)
+            printf "0"
+            ;;
+    esac
+}
+
+# Print the YAML tag of the node in a file: !!seq, !!map, !!null, !!bool,
+# !!int, !!float or !!str
+yq_tag() {
+    _tag_kind=$(yq_node_kind "$1")
+    if [ "$_tag_kind" != "scalar" ]; then
+        echo "!!$_tag_kind"
+        return
+    fi
+    awk '
+    /^[[:space:]]*(#.*)?$/ || /^(---|\.\.\.)/ {
+        next
+    }
+    {
+        v = $0
+        gsub(/^[[:space:]]+|[[:space:]]+$/, "", v)
+        exit
+    }
+    END {
+        if (v == "" || v == "null" || v == "~") print "!!null"
+        else if (v ~ /^\[/) print "!!seq"
+        else if (v ~ /^\{/) print "!!map"
+        else if (v == "true" || v == "false") print "!!bool"
+        else if (v ~ /^[-+]?[0-9][0-9_]*$/ || v ~ /^0x[0-9a-fA-F]+$/) print "!!int"
+        else if (v ~ /^[-+]?([0-9][0-9_]*)?\.?[0-9]+([eE][-+]?[0-9]+)?$/ || v ~ /^[-+]?\.(inf|Inf|INF)$/ || v ~ /^\.(nan|NaN|NAN)$/) print "!!float"
+        else print "!!str"
+    }
+    ' "$1"
+}
+
+# Iterate over array or object elements
+# Each item is printed as a standalone node, items are separated by a blank line
+# Scalars other than null cannot be iterated
+yq_iterate() {
+    _file="$1"
+
+    if [ "$(yq_node_kind "$_file")" = "scalar" ]; then
+        _iter_tag=$(yq_tag "$_file")
+        if [ "$_iter_tag" = "!!str" ] || [ "$_iter_tag" = "!!int" ] || [ "$_iter_tag" = "!!float" ] || [ "$_iter_tag" = "!!bool" ]; then
+            _yq_error "cannot iterate over $_iter_tag"
+            return
         fi
-    else
-        # Object iteration (return values)
-        awk '
-        /^[a-zA-Z_]/ {
-            if ($0 ~ /:/) {
-                sub(/^[^:]*: */, "")
-                print
-            }
-        }
-        ' "$_file"
     fi
+
+    _iter_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _iter_count=$(yq_split_items "$_file" "$_iter_base")
+
+    _iter_i=1
+    while [ "$_iter_i" -le "$_iter_count" ]; do
+        if [ "$_iter_i" -gt 1 ]; then
+            printf "\n"
+        fi
+        cat "$_iter_base.$_iter_i"
+        rm -f "$_iter_base.$_iter_i"
+        _iter_i=$((_iter_i + 1))
+    done
+    rm -f "$_iter_base"
 }
 
 # Access array by index or slice
@@ -205,104 +841,82 @@ yq_array_access() {
     # Extract index/slice from brackets
     _inner=$(echo "$_spec" | sed 's/\[\(.*\)\]/\1/')
 
+    # Only sequences can be indexed
+    if [ "$(yq_node_kind "$_file")" != "seq" ]; then
+        echo "null"
+        return
+    fi
+
+    _acc_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _acc_count=$(yq_split_items "$_file" "$_acc_base")
+
     # Check if it's a slice (contains :)
     if echo "$_inner" | grep -q ':'; then
         # Slice operation
         _start=$(echo "$_inner" | cut -d: -f1)
         _end=$(echo "$_inner" | cut -d: -f2)
 
-        # Default values
+        # Default values, negative bounds count from the end
         [ -z "$_start" ] && _start=0
-        [ -z "$_end" ] && _end=9999999
+        [ -z "$_end" ] && _end=$_acc_count
+        [ "$_start" -lt 0 ] && _start=$((_acc_count + _start))
+        [ "$_end" -lt 0 ] && _end=$((_acc_count + _end))
+        [ "$_start" -lt 0 ] && _start=0
+        [ "$_end" -gt "$_acc_count" ] && _end=$_acc_count
 
-        awk -v start="$_start" -v end="$_end" '
-        BEGIN {
-            idx = 0
-        }
-        /^-/ {
-            if (idx >= start && idx < end) {
-                print
-            }
-            idx++
-            if (idx >= end) exit
-        }
-        ' "$_file"
+        # Re-emit each selected item as a sequence entry
+        _acc_i=$_start
+        while [ "$_acc_i" -lt "$_end" ]; do
+            awk '
+            NR == 1 { print "- " $0; next }
+            /^$/ { print; next }
+            { print "  " $0 }
+            ' "$_acc_base.$((_acc_i + 1))"
+            _acc_i=$((_acc_i + 1))
+        done
     else
-        # Single index access
+        # Single index access, negative indices count from the end
         _idx="$_inner"
+        if [ "$_idx" -lt 0 ]; then
+            _idx=$((_acc_count + _idx))
+        fi
 
-        # Handle negative indices
-        if echo "$_idx" | grep -q '^-'; then
-            # Negative index - count from end
-            _pos_idx=$(echo "$_idx" | sed 's/^-//')
-
-            # Count total array elements
-            _total=$(grep -c '^-' "$_file")
-            _target_idx=$((_total - _pos_idx))
-
-            awk -v target="$_target_idx" '
-            BEGIN {
-                idx = 0
-            }
-            /^-/ {
-                if (idx == target) {
-                    sub(/^- /, "")
-                    print
-                    exit
-                }
-                idx++
-            }
-            ' "$_file"
+        if [ "$_idx" -ge 0 ] && [ "$_idx" -lt "$_acc_count" ]; then
+            cat "$_acc_base.$((_idx + 1))"
         else
-            # Positive index - print the entire object/item at this index
-            awk -v target="$_idx" '
-            BEGIN {
-                idx = 0
-                found = 0
-                in_item = 0
-            }
-            /^-/ {
-                # Start of a new array item
-                if (found == 1) {
-                    # We were printing a previous item, stop now
-                    exit
-                }
-                if (idx == target) {
-                    # Found the target index
-                    found = 1
-                    in_item = 1
-                    sub(/^- /, "")
-                    print
-                } else {
-                    idx++
-                }
-                next
-            }
-            /^[^ -]/ && found == 1 {
-                # Non-indented line after finding target = next item
-                exit
-            }
-            found == 1 && in_item == 1 {
-                # We'"'"'re in the target item, print all lines
-                print
-            }
-            ' "$_file"
+            echo "null"
         fi
     fi
+
+    _acc_i=1
+    while [ "$_acc_i" -le "$_acc_count" ]; do
+        rm -f "$_acc_base.$_acc_i"
+        _acc_i=$((_acc_i + 1))
+    done
+    rm -f "$_acc_base"
 }
 
-# Get length of array or object
+# Get length of array or object (number of characters for strings)
 yq_length() {
     _file="$1"
 
-    # Check if it's an array (starts with -)
-    if head -n 1 "$_file" | grep -q '^-'; then
-        # Count array elements
-        _count=$(grep -c '^-' "$_file")
-    else
-        # Count object keys
-        _count=$(grep -c '^[a-zA-Z_][a-zA-Z0-9_]*:' "$_file")
-    fi
+    case "$(yq_node_kind "$_file")" in
+        seq|map)
+            _len_base=$(mktemp -p "$_YQ_TEMP_DIR")
+            _count=$(yq_split_items "$_file" "$_len_base")
+            rm -f "$_len_base"*
+            ;;
+        *)
+            _count=$(awk '
+            { text = (NR == 1) ? $0 : text "\n" $0 }
+            END {
+                if (text == "null" || text == "~" || text == "") { print 0; exit }
+                if (text ~ /^".*"$/ || text ~ /^'"'"'.*'"'"'$/) text = substr(text, 2, length(text) - 2)
+                print length(text)
+            }
+            ' "$_file")
+            ;;
+    esac
     printf "%s" "$_count"
 }
 
@@ -366,11 +980,27 @@ yq_has() {
     _key="$1"
     _file="$2"
 
-    if grep -q "^$_key:" "$_file" || grep -q "^[[:space:]]*$_key:" "$_file"; then
-        printf "true"
-    else
-        printf "false"
-    fi
+    # Only keys of the mapping itself count, not keys of nested mappings
+    awk -v key="$_key" '` + awkMapKey + `
+    BEGIN {
+        map_indent = -1
+        found = 0
+    }
+    /^[[:space:]]*(#.*)?$/ || /^(---|\.\.\.)/ {
+        next
+    }
+    {
+        match($0, /^ */)
+        if (map_indent == -1) map_indent = RLENGTH
+        if (RLENGTH == map_indent && yq_line_key($0) == key) {
+            found = 1
+            exit
+        }
+    }
+    END {
+        printf "%s", found ? "true" : "false"
+    }
+    ' "$_file"
 }
 `
 }
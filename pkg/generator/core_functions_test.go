@@ -59,6 +59,16 @@ func TestYqUnquote(t *testing.T) {
 			input:    "42",
 			expected: "42",
 		},
+		{
+			name:     "escapes are decoded",
+			input:    `"a\tb \"c\" \\ caf\u00e9"`,
+			expected: "a\tb \"c\" \\ café",
+		},
+		{
+			name:     "single-quoted string",
+			input:    `'it''s'`,
+			expected: "it's",
+		},
 	}
 
 	for _, tt := range tests {
@@ -137,3 +147,220 @@ func TestYqIterate(t *testing.T) {
 		}
 	})
 }
+
+func TestYqBlockScalarKeyAccess(t *testing.T) {
+	code := GenerateCoreFunctions()
+	tester := NewShellFunctionTester(t, code)
+	defer tester.Cleanup()
+
+	input := "script: |\n    echo a\n\n    echo b\ndesc: >-\n  folded\n  text\nname: x"
+
+	// Block scalars are re-emitted with the header and a two-space body
+	t.Run("literal block", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", input)
+		tester.ExecuteFunctionExpect("|\n  echo a\n\n  echo b", "yq_key_access", "script", testFile)
+	})
+
+	t.Run("folded block with strip chomping", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", input)
+		tester.ExecuteFunctionExpect(">-\n  folded\n  text", "yq_key_access", "desc", testFile)
+	})
+
+	t.Run("key after block", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", input)
+		tester.ExecuteFunctionExpect("x", "yq_key_access", "name", testFile)
+	})
+}
+
+func TestYqBlockScalarDecode(t *testing.T) {
+	code := GenerateCoreFunctions()
+	tester := NewShellFunctionTester(t, code)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "literal clip",
+			input:    "|\n  a\n  b\n",
+			expected: "a\nb\n",
+		},
+		{
+			name:     "literal strip",
+			input:    "|-\n  a\n  b\n",
+			expected: "a\nb",
+		},
+		{
+			name:     "literal keep",
+			input:    "|+\n  a\n\n\n",
+			expected: "a\n\n\n",
+		},
+		{
+			name:     "folded joins lines",
+			input:    ">\n  a\n  b\n\n  c\n",
+			expected: "a b\nc\n",
+		},
+		{
+			name:     "folded keeps more-indented lines",
+			input:    ">-\n  a\n    b\n  c\n",
+			expected: "a\n  b\nc",
+		},
+		{
+			name:     "explicit indentation indicator",
+			input:    "|2-\n    a\n  b\n",
+			expected: "  a\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", tt.input)
+			output, err := tester.ExecuteFunction("yq_block_scalar_decode", testFile)
+			if output != tt.expected {
+				t.Errorf("expected %q, got %q (error: %v)", tt.expected, output, err)
+			}
+		})
+	}
+}
+
+func TestYqKeyAccessIndentationScope(t *testing.T) {
+	code := GenerateCoreFunctions()
+	tester := NewShellFunctionTester(t, code)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		input    string
+		key      string
+		expected string
+	}{
+		{
+			name:     "nested key before top-level key",
+			input:    "metadata:\n  name: inner\nname: outer",
+			key:      "name",
+			expected: "outer",
+		},
+		{
+			name:     "same key at three levels",
+			input:    "a:\n  b:\n    b: deep\n  c: 1\nb: top",
+			key:      "b",
+			expected: "top",
+		},
+		{
+			name:     "key that is a prefix of another key",
+			input:    "name_full: long\nname: short",
+			key:      "name",
+			expected: "short",
+		},
+		{
+			name:     "key only present in nested mapping",
+			input:    "spec:\n  replicas: 3",
+			key:      "replicas",
+			expected: "null",
+		},
+		{
+			name:     "nested key inside block scalar is ignored",
+			input:    "script: |\n  name: fake\nname: real",
+			key:      "name",
+			expected: "real",
+		},
+		{
+			name:     "mapping with leading comment",
+			input:    "# name: comment\nname: value",
+			key:      "name",
+			expected: "value",
+		},
+		{
+			name:     "indented mapping",
+			input:    "  sub:\n    name: inner\n  name: outer",
+			key:      "name",
+			expected: "outer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", tt.input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_key_access", tt.key, testFile)
+		})
+	}
+}
+
+func TestYqHasIndentationScope(t *testing.T) {
+	code := GenerateCoreFunctions()
+	tester := NewShellFunctionTester(t, code)
+	defer tester.Cleanup()
+
+	t.Run("top-level key", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "metadata:\n  name: x\nkind: Pod")
+		tester.ExecuteFunctionExpect("true", "yq_has", "kind", testFile)
+	})
+
+	t.Run("nested key is not a key of the mapping", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "metadata:\n  name: x\nkind: Pod")
+		tester.ExecuteFunctionExpect("false", "yq_has", "name", testFile)
+	})
+
+	t.Run("prefix of an existing key", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "namespace: ns")
+		tester.ExecuteFunctionExpect("false", "yq_has", "name", testFile)
+	})
+}
+
+func TestYqBlockSequenceForms(t *testing.T) {
+	code := GenerateCoreFunctions()
+	tester := NewShellFunctionTester(t, code)
+	defer tester.Cleanup()
+
+	t.Run("sequence at the same indentation as its key", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "items:\n- a\n- b\nnext: 1")
+		tester.ExecuteFunctionExpect("- a\n- b", "yq_key_access", "items", testFile)
+	})
+
+	t.Run("indented sequence", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "  - a\n  - b\n  - c")
+		tester.ExecuteFunctionExpect("c", "yq_array_access", "[2]", testFile)
+	})
+
+	t.Run("nested sequence item", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "- - a\n  - b\n- - c")
+		tester.ExecuteFunctionExpect("- a\n- b", "yq_array_access", "[0]", testFile)
+	})
+
+	t.Run("compact map item with deeper children", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "- name: x\n  tags:\n    - t1\n- name: y")
+		tester.ExecuteFunctionExpect("name: x\ntags:\n  - t1", "yq_array_access", "[0]", testFile)
+	})
+
+	t.Run("item content on the next line", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "-\n  name: x\n- name: y")
+		tester.ExecuteFunctionExpect("name: x", "yq_array_access", "[0]", testFile)
+	})
+
+	t.Run("slice re-emits sequence entries", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "- a\n- name: b\n  v: 1\n- c")
+		tester.ExecuteFunctionExpect("- name: b\n  v: 1\n- c", "yq_array_access", "[1:]", testFile)
+	})
+
+	t.Run("out of range index is null", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "- a")
+		tester.ExecuteFunctionExpect("null", "yq_array_access", "[3]", testFile)
+	})
+
+	t.Run("iterate multi-line items", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "- name: x\n  v: 1\n- - a\n  - b")
+		tester.ExecuteFunctionExpect("name: x\nv: 1\n\n- a\n- b", "yq_iterate", testFile)
+	})
+
+	t.Run("length of indented sequence", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "  - a\n  - - b\n    - c")
+		tester.ExecuteFunctionExpect("2", "yq_length", testFile)
+	})
+
+	t.Run("length of string", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "hello")
+		tester.ExecuteFunctionExpect("5", "yq_length", testFile)
+	})
+}
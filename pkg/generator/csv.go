@@ -0,0 +1,207 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+// GenerateCSV returns the CSV and TSV output and input conversion functions
+func GenerateCSV() string {
+	return `
+# Convert YAML output to CSV (or TSV with a tab separator)
+# Input: the separator and one or more YAML results separated by blank
+# lines (iteration, comma)
+# Output: the rows of every result, see _yq_csv_encode
+yq_yaml_to_csv() {
+    _csv_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    printf '%s\n' "$2" > "$_csv_base.in"
+    _csv_count=$(yq_split_results "$_csv_base.in" "$_csv_base")
+
+    _csv_i=0
+    while [ "$_csv_i" -lt "$_csv_count" ]; do
+        _csv_i=$((_csv_i + 1))
+        _yq_csv_encode "$1" "$_csv_base.$_csv_i" || { rm -f "$_csv_base" "$_csv_base".*; return 1; }
+        echo
+    done
+    rm -f "$_csv_base" "$_csv_base".*
+}
+
+# Print a sequence as one CSV row, a sequence of sequences as one row per
+# item, or a sequence of maps as a header row with the keys of the first
+# map and one row of values per map, using the given separator
+_yq_csv_encode() (
+    _sep="$1"
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    if [ "$(yq_node_kind "$2")" != "seq" ]; then
+        rm -f "$_base"*
+        _yq_error "cannot encode !!$(yq_node_kind "$2") as csv, expected an array"
+        return 1
+    fi
+
+    _count=$(yq_split_items "$2" "$_base")
+    if [ "$_count" -gt 0 ] && [ "$(yq_node_kind "$_base.1")" = "seq" ]; then
+        _i=0
+        while [ "$_i" -lt "$_count" ]; do
+            _i=$((_i + 1))
+            [ "$_i" -gt 1 ] && echo
+            _yq_csv_encode "$_sep" "$_base.$_i" || { rm -f "$_base"*; return 1; }
+        done
+        rm -f "$_base"*
+        return
+    fi
+
+    if [ "$_count" -gt 0 ] && [ "$(yq_node_kind "$_base.1")" = "map" ]; then
+        yq_map_keys "$_base.1" > "$_base.header"
+        _k=0
+        while IFS= read -r _key || [ -n "$_key" ]; do
+            _k=$((_k + 1))
+            [ "$_k" -gt 1 ] && printf '%s' "$_sep"
+            printf '%s' "$_key" > "$_base.field"
+            _yq_csv_field "$_sep" "$_base.field"
+        done < "$_base.header"
+
+        _i=0
+        while [ "$_i" -lt "$_count" ]; do
+            _i=$((_i + 1))
+            echo
+            if [ "$(yq_node_kind "$_base.$_i")" != "map" ]; then
+                rm -f "$_base"*
+                _yq_error "cannot encode !!$(yq_node_kind "$_base.$_i") as a csv row, expected a map"
+                return 1
+            fi
+            yq_map_keys "$_base.$_i" > "$_base.keys"
+            yq_split_items "$_base.$_i" "$_base.row" > /dev/null
+            _k=0
+            while IFS= read -r _key || [ -n "$_key" ]; do
+                _k=$((_k + 1))
+                [ "$_k" -gt 1 ] && printf '%s' "$_sep"
+                # Keys missing from a row give an empty field
+                _at=$(grep -nxF -- "$_key" "$_base.keys" | head -n 1 | cut -d: -f1)
+                [ -n "$_at" ] || continue
+                _yq_csv_field "$_sep" "$_base.row.$_at" || { rm -f "$_base"*; return 1; }
+            done < "$_base.header"
+            rm -f "$_base.row".*
+        done
+        rm -f "$_base"*
+        return
+    fi
+
+    _i=0
+    while [ "$_i" -lt "$_count" ]; do
+        _i=$((_i + 1))
+        [ "$_i" -gt 1 ] && printf '%s' "$_sep"
+        _yq_csv_field "$_sep" "$_base.$_i" || { rm -f "$_base"*; return 1; }
+    done
+    rm -f "$_base"*
+)
+
+# Print a scalar node as one CSV field: quoted (RFC 4180) when it contains
+# the separator, a quote or a line break, empty for null
+_yq_csv_field() {
+    case "$(yq_node_kind "$2")" in
+        seq|map)
+            _yq_error "cannot encode nested !!$(yq_node_kind "$2") as a csv field"
+            return 1
+            ;;
+    esac
+    [ "$(yq_tag "$2")" = "!!null" ] && return
+    yq_scalar_text "$2" > "$2.raw"
+    awk -v trail="$(_yq_text_trail "$2.raw")" -v sep="$1" '` + awkReadText + `
+    END {
+        t = yq_read_text()
+        if (index(t, sep) || index(t, "\"") || t ~ /[\n\r]/ || t ~ /^ /) {
+            gsub(/"/, "\"\"", t)
+            t = "\"" t "\""
+        }
+        printf "%s", t
+    }
+    ' "$2.raw"
+    rm -f "$2.raw"
+}
+
+# Parse CSV (or TSV with a tab separator) into a sequence of maps keyed by
+# the header row. Quoted fields may hold separators, doubled quotes and line
+# breaks. With auto parsing numbers and booleans keep their type, every
+# other field is a string
+yq_csv_to_yaml() {
+    LC_ALL=C awk -v sep="$1" -v auto="$2" '` + awkScalarQuote + `
+    {
+        text = (NR == 1) ? $0 : text "\n" $0
+    }
+    function value(v) {
+        if (auto && v ~ /^(true|false|-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?)$/) return v
+        return yq_quote(v)
+    }
+    # Write a field of the current row, the header row only names them
+    function field(v) {
+        col++
+        if (row == 1) {
+            header[col] = v
+            cols = col
+        } else if (col <= cols) {
+            print ((col == 1) ? "- " : "  ") yq_quote(header[col]) ": " value(v)
+        }
+    }
+    END {
+        n = length(text)
+        row = 1
+        col = 0
+        rows = 0
+        pos = 1
+        while (pos <= n) {
+            c = substr(text, pos, 1)
+            if (col == 0 && c == "\n") {
+                # Blank lines are not rows
+                pos++
+                continue
+            }
+            v = ""
+            if (c == "\"") {
+                # Quoted field: "" is a quote, everything else is kept
+                pos++
+                while (pos <= n) {
+                    c = substr(text, pos, 1)
+                    if (c == "\"" && substr(text, pos + 1, 1) == "\"") {
+                        v = v "\""
+                        pos += 2
+                    } else if (c == "\"") {
+                        pos++
+                        break
+                    } else {
+                        v = v c
+                        pos++
+                    }
+                }
+            }
+            while (pos <= n && (c = substr(text, pos, 1)) != sep && c != "\n") {
+                if (c != "\r") v = v c
+                pos++
+            }
+            field(v)
+            if (pos <= n && substr(text, pos, 1) == sep) {
+                pos++
+                if (pos <= n && substr(text, pos, 1) != "\n") continue
+                # A separator at the end of a line is followed by an empty field
+                field("")
+            }
+            if (row > 1) rows++
+            row++
+            col = 0
+            pos++
+        }
+        if (rows == 0) print "[]"
+    }
+    ' "$3"
+}
+`
+}
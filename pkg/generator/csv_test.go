@@ -0,0 +1,92 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"testing"
+)
+
+func newCSVTester(t *testing.T) *ShellFunctionTester {
+	return NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateCoreFunctions(),
+		GenerateEncoding(),
+		GenerateCSV(),
+	)
+}
+
+// TestYqCSVEncode verifies sequences are written as CSV rows
+func TestYqCSVEncode(t *testing.T) {
+	tester := newCSVTester(t)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		sep      string
+		input    string
+		expected string
+	}{
+		{name: "one row", sep: ",", input: "- a\n- 1\n- null\n- true", expected: "a,1,,true"},
+		{name: "rows", sep: ",", input: "- - a\n  - b\n- - c\n  - d", expected: "a,b\nc,d"},
+		{name: "quoting", sep: ",", input: "- \"x, y\"\n- 'say \"hi\"'\n- \"a\\nb\"", expected: "\"x, y\",\"say \"\"hi\"\"\",\"a\nb\""},
+		{name: "maps with header", sep: ",", input: "- name: a\n  v: 1\n- v: 2\n  name: b\n- name: c", expected: "name,v\na,1\nb,2\nc,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", tt.input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; _yq_csv_encode", tt.sep, testFile)
+		})
+	}
+
+	t.Run("tab separator", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "- a b\n- \"c\td\"")
+		tester.ExecuteFunctionExpect("a b\t\"c\td\"", "_yq_init_temp_dir; _yq_csv_encode \"$(printf '\\t')\"", testFile)
+	})
+
+	t.Run("map is an error", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "a: 1")
+		tester.ExecuteFunctionExpectError("_yq_init_temp_dir; _yq_csv_encode", ",", testFile)
+	})
+}
+
+// TestYqCSVToYaml verifies CSV and TSV input is parsed into maps
+func TestYqCSVToYaml(t *testing.T) {
+	tester := newCSVTester(t)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		sep      string
+		auto     string
+		input    string
+		expected string
+	}{
+		{name: "header and rows", sep: ",", auto: "1", input: "name,port\napi,80\nweb,8080\n", expected: "- name: api\n  port: 80\n- name: web\n  port: 8080"},
+		{name: "auto parse off", sep: ",", auto: "0", input: "name,port,on\napi,80,true", expected: "- name: api\n  port: \"80\"\n  on: \"true\""},
+		{name: "quoted fields", sep: ",", auto: "1", input: "a,b\n\"x, y\",\"say \"\"hi\"\"\"", expected: "- a: x, y\n  b: \"say \\\"hi\\\"\""},
+		{name: "line break in field", sep: ",", auto: "1", input: "a,b\n\"l1\nl2\",2", expected: "- a: \"l1\\nl2\"\n  b: 2"},
+		{name: "crlf and trailing separator", sep: ",", auto: "1", input: "a,b\r\n1,\r\n", expected: "- a: 1\n  b: \"\""},
+		{name: "tabs", sep: "\t", auto: "1", input: "a\tb\nx y\t1.5", expected: "- a: x y\n  b: 1.5"},
+		{name: "header only", sep: ",", auto: "1", input: "a,b", expected: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.csv", tt.input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_csv_to_yaml", tt.sep, tt.auto, testFile)
+		})
+	}
+}
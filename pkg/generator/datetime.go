@@ -0,0 +1,602 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+// awkDatetime holds the awk date math: civil dates to days since the epoch
+// and back, parsing and formatting with Go reference layouts (the format
+// yq uses, "2006-01-02T15:04:05Z07:00") and Go durations ("1h30m").
+// Instants are kept as whole seconds (dt_secs), nanoseconds (dt_ns) and the
+// zone offset in seconds (dt_off). Times are printed as RFC3339 with the
+// fraction of a second when there is one. Zone offsets come from TZif files
+// (dumped by od) and POSIX TZ rules. Large integers are printed with %.0f
+// since some awks print them in exponent form.
+const awkDatetime = `
+    BEGIN {
+        split("January February March April May June July August September October November December", dt_months, " ")
+        split("Sunday Monday Tuesday Wednesday Thursday Friday Saturday", dt_wdays, " ")
+        dt_ntok = split("January Monday -07:00:00 Z07:00:00 -07:00 Z07:00 -0700 Z0700 2006 -07 Z07 MST Jan Mon 002 __2 _2 01 02 03 04 05 06 15 PM pm 1 2 3 4 5", dt_toks, " ")
+        dt_rfc3339 = "2006-01-02T15:04:05Z07:00"
+        dt_rfc3339_nano = "2006-01-02T15:04:05.999999999Z07:00"
+    }
+    function dt_floor(x) {
+        return (x == int(x) || x >= 0) ? int(x) : int(x) - 1
+    }
+    # Days since 1970-01-01 of a proleptic Gregorian date
+    function dt_days(y, m, d,    era, yoe, doy) {
+        y -= (m <= 2)
+        era = dt_floor(y / 400)
+        yoe = y - era * 400
+        doy = int((153 * (m + (m > 2 ? -3 : 9)) + 2) / 5) + d - 1
+        return era * 146097 + yoe * 365 + int(yoe / 4) - int(yoe / 100) + doy - 719468
+    }
+    # Set dt_y, dt_m and dt_d from days since 1970-01-01
+    function dt_civil(z,    era, doe, yoe, doy, mp) {
+        z += 719468
+        era = dt_floor(z / 146097)
+        doe = z - era * 146097
+        yoe = int((doe - int(doe / 1460) + int(doe / 36524) - int(doe / 146096)) / 365)
+        doy = doe - (365 * yoe + int(yoe / 4) - int(yoe / 100))
+        mp = int((5 * doy + 2) / 153)
+        dt_d = doy - int((153 * mp + 2) / 5) + 1
+        dt_m = mp + (mp < 10 ? 3 : -9)
+        dt_y = yoe + era * 400 + (dt_m <= 2)
+    }
+    # The layout token starting at offset i, "" for a literal character
+    function dt_token(layout, i,    k, rest) {
+        rest = substr(layout, i)
+        if (match(rest, /^[.,](0+|9+)/) && substr(rest, RLENGTH + 1, 1) !~ /[0-9]/) return substr(rest, 1, RLENGTH)
+        for (k = 1; k <= dt_ntok; k++) {
+            if (substr(rest, 1, length(dt_toks[k])) == dt_toks[k]) return dt_toks[k]
+        }
+        return ""
+    }
+    function dt_zone(off, tok,    sign, h, m, s) {
+        if (off == 0 && substr(tok, 1, 1) == "Z") return "Z"
+        if (tok == "MST") return (off == 0) ? "UTC" : dt_zone(off, "-0700")
+        sign = (off < 0) ? "-" : "+"
+        if (off < 0) off = -off
+        h = sprintf("%02d", int(off / 3600))
+        m = sprintf("%02d", int(off % 3600 / 60))
+        s = sprintf("%02d", off % 60)
+        sub(/^Z/, "-", tok)
+        if (tok == "-07") return sign h
+        if (tok == "-0700") return sign h m
+        if (tok == "-07:00") return sign h ":" m
+        return sign h ":" m ":" s
+    }
+    # Format an instant with a layout ("" is RFC3339 with the fraction of a
+    # second when it is not zero)
+    function dt_format(secs, ns, off, layout,    t, days, sod, hh, mm, ss, i, tok, out, frac) {
+        if (layout == "") layout = dt_rfc3339_nano
+        t = secs + off
+        days = dt_floor(t / 86400)
+        sod = t - days * 86400
+        dt_civil(days)
+        hh = int(sod / 3600)
+        mm = int(sod % 3600 / 60)
+        ss = sod % 60
+        out = ""
+        for (i = 1; i <= length(layout); i += length(tok)) {
+            tok = dt_token(layout, i)
+            if (tok == "") {
+                tok = substr(layout, i, 1)
+                out = out tok
+            } else if (tok == "2006") out = out sprintf("%04d", dt_y)
+            else if (tok == "06") out = out sprintf("%02d", dt_y % 100)
+            else if (tok == "01") out = out sprintf("%02d", dt_m)
+            else if (tok == "1") out = out dt_m
+            else if (tok == "January") out = out dt_months[dt_m]
+            else if (tok == "Jan") out = out substr(dt_months[dt_m], 1, 3)
+            else if (tok == "02") out = out sprintf("%02d", dt_d)
+            else if (tok == "_2") out = out sprintf("%2d", dt_d)
+            else if (tok == "2") out = out dt_d
+            else if (tok == "002" || tok == "__2") out = out sprintf(tok == "002" ? "%03d" : "%3d", days - dt_days(dt_y, 1, 1) + 1)
+            else if (tok == "Monday") out = out dt_wdays[(days % 7 + 11) % 7 + 1]
+            else if (tok == "Mon") out = out substr(dt_wdays[(days % 7 + 11) % 7 + 1], 1, 3)
+            else if (tok == "15") out = out sprintf("%02d", hh)
+            else if (tok == "03") out = out sprintf("%02d", (hh + 11) % 12 + 1)
+            else if (tok == "3") out = out ((hh + 11) % 12 + 1)
+            else if (tok == "04") out = out sprintf("%02d", mm)
+            else if (tok == "4") out = out mm
+            else if (tok == "05") out = out sprintf("%02d", ss)
+            else if (tok == "5") out = out ss
+            else if (tok == "PM") out = out (hh < 12 ? "AM" : "PM")
+            else if (tok == "pm") out = out (hh < 12 ? "am" : "pm")
+            else if (tok ~ /^[.,]/) {
+                frac = substr(sprintf("%09d", ns), 1, length(tok) - 1)
+                if (tok ~ /9/) {
+                    sub(/0+$/, "", frac)
+                    if (frac != "") out = out substr(tok, 1, 1) frac
+                } else {
+                    out = out substr(tok, 1, 1) frac
+                }
+            }
+            else out = out dt_zone(off, tok)
+        }
+        return out
+    }
+    # Read a number of at most max digits (at least min) at dt_pos of s
+    function dt_num(s, min, max,    n) {
+        n = 0
+        while (n < max && substr(s, dt_pos + n, 1) ~ /[0-9]/) n++
+        if (n < min) {
+            dt_bad = 1
+            return 0
+        }
+        dt_pos += n
+        return substr(s, dt_pos - n, n) + 0
+    }
+    function dt_name(s, names, count, len,    k, name) {
+        for (k = 1; k <= count; k++) {
+            name = (len > 0) ? substr(names[k], 1, len) : names[k]
+            if (tolower(substr(s, dt_pos, length(name))) == tolower(name)) {
+                dt_pos += length(name)
+                return k
+            }
+        }
+        dt_bad = 1
+        return 0
+    }
+    function dt_fraction(s,    digits) {
+        digits = ""
+        while (substr(s, dt_pos, 1) ~ /[0-9]/) {
+            digits = digits substr(s, dt_pos, 1)
+            dt_pos++
+        }
+        return substr(digits "000000000", 1, 9) + 0
+    }
+    # Parse a time with a layout ("" accepts RFC3339, a space instead of the T
+    # and a date alone). Sets dt_secs, dt_ns and dt_off, returns 0 on failure
+    function dt_parse(s, layout,    i, tok, y, mo, d, hh, mm, ss, pm, sign, c) {
+        if (layout == "") {
+            if (s ~ /^[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]$/) s = s "T00:00:00Z"
+            sub(/^[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9] /, substr(s, 1, 10) "T", s)
+            layout = dt_rfc3339
+        }
+        y = 1; mo = 1; d = 1; hh = 0; mm = 0; ss = 0; pm = -1
+        dt_ns = 0
+        dt_off = 0
+        dt_bad = 0
+        dt_pos = 1
+        for (i = 1; i <= length(layout) && !dt_bad; i += length(tok)) {
+            tok = dt_token(layout, i)
+            if (tok == "") {
+                tok = substr(layout, i, 1)
+                if (substr(s, dt_pos, 1) != tok) return 0
+                dt_pos++
+            } else if (tok == "2006") y = dt_num(s, 4, 4)
+            else if (tok == "06") {
+                y = dt_num(s, 2, 2)
+                y += (y >= 69) ? 1900 : 2000
+            }
+            else if (tok == "01" || tok == "1") mo = dt_num(s, length(tok) == 2 ? 2 : 1, 2)
+            else if (tok == "January") mo = dt_name(s, dt_months, 12, 0)
+            else if (tok == "Jan") mo = dt_name(s, dt_months, 12, 3)
+            else if (tok == "02" || tok == "2") d = dt_num(s, length(tok) == 2 ? 2 : 1, 2)
+            else if (tok == "_2") {
+                if (substr(s, dt_pos, 1) == " ") dt_pos++
+                d = dt_num(s, 1, 2)
+            }
+            else if (tok == "002" || tok == "__2") {
+                while (substr(s, dt_pos, 1) == " ") dt_pos++
+                d = dt_num(s, 1, 3)
+                mo = 0
+            }
+            else if (tok == "Monday") dt_name(s, dt_wdays, 7, 0)
+            else if (tok == "Mon") dt_name(s, dt_wdays, 7, 3)
+            else if (tok == "15") hh = dt_num(s, 1, 2)
+            else if (tok == "03" || tok == "3") hh = dt_num(s, length(tok), 2) % 12
+            else if (tok == "04" || tok == "4") mm = dt_num(s, length(tok), 2)
+            else if (tok == "05" || tok == "5") {
+                ss = dt_num(s, length(tok), 2)
+                # A fraction is accepted after the seconds even if the layout has none
+                if (substr(s, dt_pos, 1) ~ /[.,]/ && substr(s, dt_pos + 1, 1) ~ /[0-9]/ && substr(layout, i + length(tok), 1) !~ /[.,]/) {
+                    dt_pos++
+                    dt_ns = dt_fraction(s)
+                }
+            }
+            else if (tok == "PM" || tok == "pm") {
+                c = toupper(substr(s, dt_pos, 2))
+                if (c != "AM" && c != "PM") return 0
+                pm = (c == "PM")
+                dt_pos += 2
+            }
+            else if (tok ~ /^[.,]/) {
+                if (substr(s, dt_pos, 1) ~ /[.,]/) {
+                    dt_pos++
+                    dt_ns = dt_fraction(s)
+                } else if (tok ~ /0/) return 0
+            }
+            else if (tok == "MST") {
+                c = substr(s, dt_pos)
+                if (!match(c, /^[A-Z][A-Z][A-Z]?[A-Z]?/)) return 0
+                dt_pos += RLENGTH
+            }
+            else {
+                c = substr(s, dt_pos, 1)
+                if (c == "Z" && substr(tok, 1, 1) == "Z") {
+                    dt_pos++
+                } else if (c == "+" || c == "-") {
+                    sign = (c == "-") ? -1 : 1
+                    dt_pos++
+                    dt_off = dt_num(s, 2, 2) * 3600
+                    if (substr(s, dt_pos, 1) == ":") dt_pos++
+                    if (substr(s, dt_pos, 1) ~ /[0-9]/) dt_off += dt_num(s, 2, 2) * 60
+                    if (substr(s, dt_pos, 1) == ":") {
+                        dt_pos++
+                        dt_off += dt_num(s, 2, 2)
+                    }
+                    dt_off *= sign
+                } else {
+                    return 0
+                }
+            }
+        }
+        if (dt_bad || dt_pos <= length(s)) return 0
+        if (pm == 1) hh += 12
+        if (mo == 0) {
+            # Day of the year
+            dt_secs = (dt_days(y, 1, 1) + d - 1) * 86400
+        } else {
+            if (mo < 1 || mo > 12 || d < 1 || d > 31) return 0
+            dt_secs = dt_days(y, mo, d) * 86400
+        }
+        if (hh > 23 || mm > 59 || ss > 60) return 0
+        dt_secs += hh * 3600 + mm * 60 + ss - dt_off
+        return 1
+    }
+    # Parse a Go duration (300ms, -1.5h, 2h45m) into dur_secs and dur_ns,
+    # returns 0 when the text is not a duration
+    function dt_duration(s,    sign, v, u, f, total, whole) {
+        dur_secs = 0
+        dur_ns = 0
+        sign = 1
+        if (s ~ /^[-+]/) {
+            sign = (substr(s, 1, 1) == "-") ? -1 : 1
+            s = substr(s, 2)
+        }
+        if (s == "0") return 1
+        if (s !~ /^([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h)/) return 0
+        while (s != "") {
+            if (!match(s, /^([0-9]+(\.[0-9]*)?|\.[0-9]+)/)) return 0
+            v = substr(s, 1, RLENGTH) + 0
+            s = substr(s, RLENGTH + 1)
+            if (!match(s, /^(ns|us|µs|ms|s|m|h)/)) return 0
+            u = substr(s, 1, RLENGTH)
+            s = substr(s, RLENGTH + 1)
+            f = (u == "h") ? 3600 : (u == "m") ? 60 : (u == "s") ? 1 : (u == "ms") ? 0.001 : (u == "ns") ? 0.000000001 : 0.000001
+            total = v * f
+            whole = int(total)
+            dur_secs += whole
+            dur_ns += int((total - whole) * 1000000000 + 0.5)
+        }
+        dur_secs *= sign
+        dur_ns *= sign
+        return 1
+    }
+    # Add seconds and nanoseconds to dt_secs and dt_ns, keeping 0 <= dt_ns < 1e9
+    function dt_add(secs, ns) {
+        dt_secs += secs
+        dt_ns += ns
+        dt_secs += dt_floor(dt_ns / 1000000000)
+        dt_ns -= dt_floor(dt_ns / 1000000000) * 1000000000
+    }
+    # Offset in seconds of a time zone at an instant: UTC, a fixed offset
+    # (+05:30, -0800), a zone whose TZif file was dumped into zonefile or a
+    # POSIX TZ rule (EST5EDT,M3.2.0,M11.1.0). "" when the zone is unknown
+    function dt_zone_offset(zone, secs, zonefile,    sign, s) {
+        if (zone ~ /^(UTC|Z|GMT|)$/) return 0
+        if (zone ~ /^[-+][0-9][0-9](:?[0-9][0-9])?$/) {
+            sign = (substr(zone, 1, 1) == "-") ? -1 : 1
+            s = substr(zone, 2)
+            gsub(/:/, "", s)
+            return sign * (substr(s, 1, 2) * 3600 + substr(s, 3, 2) * 60)
+        }
+        if (zonefile != "") return dt_tzif_offset(zonefile, secs)
+        return dt_rule_offset(zone, secs)
+    }
+    # Signed big-endian integer of n bytes at p of the TZif bytes
+    function dt_tzif_int(p, n,    v, k) {
+        v = 0
+        if (dt_tzb[p] < 128) {
+            for (k = 0; k < n; k++) v = v * 256 + dt_tzb[p + k]
+            return v
+        }
+        for (k = 0; k < n; k++) v = v * 256 + 255 - dt_tzb[p + k]
+        return -v - 1
+    }
+    # Read the counts of the TZif header starting at p
+    function dt_tzif_counts(p) {
+        dt_tz_ut = dt_tzif_int(p + 20, 4)
+        dt_tz_std = dt_tzif_int(p + 24, 4)
+        dt_tz_leap = dt_tzif_int(p + 28, 4)
+        dt_tz_time = dt_tzif_int(p + 32, 4)
+        dt_tz_type = dt_tzif_int(p + 36, 4)
+        dt_tz_char = dt_tzif_int(p + 40, 4)
+    }
+    # Offset at an instant from a TZif file (one byte per number, as printed
+    # by od -An -tu1): the type of the last transition before the instant,
+    # the footer rule of version 2 files after the last transition
+    function dt_tzif_offset(file, secs,    line, fld, nb, n, k, d, tsize, p, tt, foot, lo, hi, mid, i) {
+        nb = 0
+        while ((getline line < file) > 0) {
+            n = split(line, fld, " ")
+            for (k = 1; k <= n; k++) dt_tzb[++nb] = fld[k] + 0
+        }
+        close(file)
+        if (nb < 44 || dt_tzb[1] != 84 || dt_tzb[2] != 90 || dt_tzb[3] != 105 || dt_tzb[4] != 102) return ""
+        d = 1
+        tsize = 4
+        dt_tzif_counts(d)
+        if (dt_tzb[5] >= 50) {
+            # Version 2 and later repeat the data with 64-bit times
+            d += 44 + dt_tz_time * 5 + dt_tz_type * 6 + dt_tz_char + dt_tz_leap * 8 + dt_tz_std + dt_tz_ut
+            tsize = 8
+            dt_tzif_counts(d)
+        }
+        p = d + 44
+        tt = p + dt_tz_time * (tsize + 1)
+        foot = ""
+        if (tsize == 8) {
+            k = tt + dt_tz_type * 6 + dt_tz_char + dt_tz_leap * (tsize + 4) + dt_tz_std + dt_tz_ut + 1
+            for (; k <= nb && dt_tzb[k] != 10; k++) foot = foot sprintf("%c", dt_tzb[k])
+        }
+        if (dt_tz_time == 0 || secs < dt_tzif_int(p, tsize)) {
+            if (dt_tz_time == 0 && foot != "") return dt_rule_offset(foot, secs)
+            # Before the first transition: the first standard time type
+            for (i = 0; i < dt_tz_type && dt_tzb[tt + i * 6 + 4]; i++) ;
+            if (i == dt_tz_type) i = 0
+            return dt_tzif_int(tt + i * 6, 4)
+        }
+        if (foot != "" && secs >= dt_tzif_int(p + (dt_tz_time - 1) * tsize, tsize)) return dt_rule_offset(foot, secs)
+        lo = 0
+        hi = dt_tz_time - 1
+        while (lo < hi) {
+            mid = int((lo + hi + 1) / 2)
+            if (dt_tzif_int(p + mid * tsize, tsize) <= secs) lo = mid
+            else hi = mid - 1
+        }
+        i = dt_tzb[p + dt_tz_time * tsize + lo]
+        return dt_tzif_int(tt + i * 6, 4)
+    }
+    # Skip a zone name (EST or <+0330>) at dt_rp of dt_rs, 0 when there is none
+    function dt_rule_name(    k) {
+        if (substr(dt_rs, dt_rp, 1) == "<") {
+            k = index(substr(dt_rs, dt_rp), ">")
+            if (!k) return 0
+            dt_rp += k
+            return 1
+        }
+        for (k = 0; substr(dt_rs, dt_rp + k, 1) ~ /[A-Za-z]/; k++) ;
+        dt_rp += k
+        return k >= 3
+    }
+    # Read [+-]hh[:mm[:ss]] at dt_rp of dt_rs as seconds
+    function dt_rule_time(    sign, c, parts) {
+        sign = 1
+        c = substr(dt_rs, dt_rp, 1)
+        if (c == "+" || c == "-") {
+            sign = (c == "-") ? -1 : 1
+            dt_rp++
+        }
+        if (!match(substr(dt_rs, dt_rp), /^[0-9]+(:[0-9]+(:[0-9]+)?)?/)) {
+            dt_rbad = 1
+            return 0
+        }
+        split(substr(dt_rs, dt_rp, RLENGTH), parts, ":")
+        dt_rp += RLENGTH
+        return sign * (parts[1] * 3600 + parts[2] * 60 + parts[3])
+    }
+    # Local time in seconds since the epoch at which a rule date (Mm.w.d,
+    # Jn or n, optionally followed by /time) falls in year y
+    function dt_rule_instant(spec, y,    k, t, f, m, day, last, n) {
+        t = 7200
+        k = index(spec, "/")
+        if (k) {
+            dt_rs = substr(spec, k + 1)
+            dt_rp = 1
+            t = dt_rule_time()
+            if (dt_rp <= length(dt_rs)) dt_rbad = 1
+            spec = substr(spec, 1, k - 1)
+        }
+        if (spec ~ /^M[0-9][0-9]?\.[1-5]\.[0-6]$/) {
+            split(substr(spec, 2), f, ".")
+            m = f[1] + 0
+            if (m < 1 || m > 12) {
+                dt_rbad = 1
+                return 0
+            }
+            # Day f[3] of week f[2] of month m, week 5 is the last one
+            day = dt_days(y, m, 1)
+            day += (f[3] - (day % 7 + 11) % 7 + 7) % 7 + (f[2] - 1) * 7
+            last = (m == 12) ? dt_days(y + 1, 1, 1) : dt_days(y, m + 1, 1)
+            while (day >= last) day -= 7
+        } else if (spec ~ /^J[0-9]+$/) {
+            # Day 1 to 365, February 29 is never counted
+            n = substr(spec, 2) + 0
+            day = dt_days(y, 1, 1) + n - 1
+            if (n >= 60 && dt_days(y, 3, 1) - dt_days(y, 2, 1) == 29) day++
+        } else if (spec ~ /^[0-9]+$/) {
+            day = dt_days(y, 1, 1) + spec
+        } else {
+            dt_rbad = 1
+            return 0
+        }
+        return day * 86400 + t
+    }
+    # Offset at an instant from a POSIX TZ rule: std offset [dst [offset]
+    # [,start[/time],end[/time]]]. Offsets are west of UTC, DST without
+    # rules follows the US ones
+    function dt_rule_offset(rule, secs,    std, dst, f, r, y, s, e) {
+        dt_rs = rule
+        dt_rp = 1
+        dt_rbad = 0
+        if (!dt_rule_name()) return ""
+        std = -dt_rule_time()
+        if (dt_rbad) return ""
+        if (dt_rp > length(rule)) return std
+        if (!dt_rule_name()) return ""
+        dst = std + 3600
+        if (substr(rule, dt_rp, 1) ~ /[-+0-9]/) dst = -dt_rule_time()
+        if (dt_rbad) return ""
+        f = (dt_rp > length(rule)) ? ",M3.2.0,M11.1.0" : substr(rule, dt_rp)
+        if (split(f, r, ",") != 3 || r[1] != "") return ""
+        dt_civil(dt_floor((secs + std) / 86400))
+        y = dt_y
+        s = dt_rule_instant(r[2], y) - std
+        e = dt_rule_instant(r[3], y) - dst
+        if (dt_rbad) return ""
+        # Southern zones start DST late in the year and end it early
+        if (s < e) return (secs >= s && secs < e) ? dst : std
+        return (secs >= e && secs < s) ? std : dst
+    }
+`
+
+// GenerateDatetime returns the date and time operators (now, to_unix,
+// from_unix, format_datetime, tz, with_dtf and duration arithmetic)
+func GenerateDatetime() string {
+	return `
+# Run a datetime operation on the text of a scalar node
+# Operations: to_unix, from_unix, format LAYOUT, tz ZONE and add DURATION
+# (sub subtracts it). Input times are read with the with_dtf layout when one
+# is set, otherwise as RFC3339; times are printed back with the same layout
+yq_datetime() (
+    _op="$1"
+    _arg="$2"
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    yq_scalar_text "$3" > "$_base.value"
+    : > "$_base.zone"
+    [ "$_op" = "tz" ] && _yq_tz_dump "$_arg" > "$_base.zone"
+    _zonefile=""
+    [ -s "$_base.zone" ] && _zonefile="$_base.zone"
+    awk -v op="$_op" -v arg="$_arg" -v dtf="$_yq_dtf" -v zonefile="$_zonefile" -v errfile="$_base.err" '` + awkDatetime + `
+    {
+        v = (NR == 1) ? $0 : v "\n" $0
+    }
+    function fail(msg) {
+        printf "%s", msg > errfile
+        exit 1
+    }
+    END {
+        if (op == "from_unix") {
+            if (v !~ /^-?[0-9]+(\.[0-9]+)?$/) fail("from_unix expects a number of seconds, got " v)
+            whole = v
+            sub(/\..*/, "", whole)
+            frac = ""
+            if (index(v, ".")) frac = substr(v, index(v, ".") + 1)
+            dt_secs = whole + 0
+            dt_ns = substr(frac "000000000", 1, 9) + 0
+            if (v ~ /^-/ && dt_ns > 0) dt_add(0, -2 * dt_ns)
+            print dt_format(dt_secs, dt_ns, 0, dtf)
+            exit
+        }
+        if (!dt_parse(v, dtf)) fail("cannot parse \"" v "\" as a time with layout " (dtf == "" ? dt_rfc3339 : dtf))
+        if (op == "to_unix") {
+            out = sprintf("%.0f", dt_secs)
+            if (dt_ns > 0) {
+                frac = sprintf("%09d", dt_ns)
+                sub(/0+$/, "", frac)
+                out = out "." frac
+            }
+            print out
+        } else if (op == "format") {
+            print dt_format(dt_secs, dt_ns, dt_off, arg)
+        } else if (op == "tz") {
+            off = dt_zone_offset(arg, dt_secs, zonefile)
+            if (off == "") fail("unknown time zone " arg)
+            print dt_format(dt_secs, dt_ns, off, dtf)
+        } else {
+            if (!dt_duration(arg)) fail("cannot parse \"" arg "\" as a duration")
+            if (op == "sub") dt_add(-dur_secs, -dur_ns)
+            else dt_add(dur_secs, dur_ns)
+            print dt_format(dt_secs, dt_ns, dt_off, dtf)
+        }
+    }
+    ' "$_base.value" > "$_base.out"
+
+    if [ -s "$_base.err" ]; then
+        _yq_error "$(cat "$_base.err")"
+        rm -f "$_base"*
+        return 1
+    fi
+    case "$_op" in
+        to_unix) cat "$_base.out" ;;
+        *)
+            printf '%s' "$(cat "$_base.out")" > "$_base.text"
+            yq_string_scalar "$_base.text"
+            ;;
+    esac
+    rm -f "$_base"*
+)
+
+# Print the current time, in the with_dtf layout when one is set
+yq_now() {
+    _now_file=$(mktemp -p "$_YQ_TEMP_DIR")
+    date -u +%Y-%m-%dT%H:%M:%SZ > "$_now_file"
+    _yq_dtf_saved="$_yq_dtf"
+    _yq_dtf=""
+    yq_datetime to_unix "" "$_now_file" > "$_now_file.unix"
+    _yq_dtf="$_yq_dtf_saved"
+    yq_datetime from_unix "" "$_now_file.unix"
+    rm -f "$_now_file" "$_now_file.unix"
+}
+
+# Print the TZif file of a zone name (America/New_York) as one number per
+# byte for the awk zone math; nothing for offsets, POSIX rules and unknown
+# zones. TZDIR overrides where the zone files are looked up
+_yq_tz_dump() {
+    for _tz_dir in "${TZDIR:-/usr/share/zoneinfo}" /usr/share/zoneinfo /usr/lib/zoneinfo /usr/share/lib/zoneinfo; do
+        if [ -f "$_tz_dir/$1" ]; then
+            od -An -v -tu1 "$_tz_dir/$1"
+            return
+        fi
+    done
+}
+
+# Shift a time by a duration for + and -, returns 2 without output when the
+# operands are not a time and a duration so plain arithmetic applies
+yq_datetime_shift() {
+    [ "$(yq_node_kind "$2")" = "scalar" ] && [ "$(yq_node_kind "$3")" = "scalar" ] || return 2
+    _shift_dur=$(yq_scalar_text "$3")
+    printf '%s\n' "$_shift_dur" | grep -q '^[-+]\{0,1\}\([0-9.][0-9.]*\(ns\|us\|µs\|ms\|s\|m\|h\)\)\{1,\}$' || return 2
+    _shift_time=$(yq_scalar_text "$2")
+    if [ -z "$_yq_dtf" ]; then
+        printf '%s\n' "$_shift_time" | grep -q '^[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]' || return 2
+    fi
+    if [ "$1" = "-" ]; then
+        yq_datetime sub "$_shift_dur" "$2"
+    else
+        yq_datetime add "$_shift_dur" "$2"
+    fi
+}
+
+# with_dtf(layout; expr): evaluate expr reading and printing times with a
+# Go reference layout instead of RFC3339
+yq_with_dtf() {
+    _yq_split_top "$1" ";"
+    eval "_saved_dtf_${_yq_parse_depth}=\"\$_yq_dtf\""
+    eval "_dtf_expr_${_yq_parse_depth}=\"\$_yq_top_right\""
+    _dtf_layout=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$_yq_top_left" "$2" > "$_dtf_layout"
+    _yq_dtf=$(yq_scalar_text "$_dtf_layout")
+    rm -f "$_dtf_layout"
+    eval "yq_parse \"\$_dtf_expr_${_yq_parse_depth}\" \"\$2\""
+    _dtf_status=$?
+    eval "_yq_dtf=\"\$_saved_dtf_${_yq_parse_depth}\""
+    return $_dtf_status
+}
+`
+}
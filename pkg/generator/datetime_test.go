@@ -0,0 +1,84 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestYqDatetime(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "created: 2023-01-30T23:44:16Z\n" +
+		"local: 2023-03-12T01:30:00+05:30\n" +
+		"frac: 2023-01-30T23:44:16.125Z\n" +
+		"day: 2024-02-28\n" +
+		"epoch: 1675122256\n" +
+		"custom: Monday, 30-Jan-23 at 11:44PM UTC"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "to_unix", query: ".created | to_unix", expected: "1675122256"},
+		{name: "to_unix fraction", query: ".frac | to_unix", expected: "1675122256.125"},
+		{name: "to_unix offset", query: ".local | to_unix", expected: "1678564800"},
+		{name: "to_unix before epoch", query: `"1969-12-31T23:59:59Z" | to_unix`, expected: "-1"},
+		{name: "from_unix", query: ".epoch | from_unix", expected: "2023-01-30T23:44:16Z"},
+		{name: "format_datetime", query: `.created | format_datetime("Monday, 02-Jan-06 at 3:04PM MST")`, expected: "Monday, 30-Jan-23 at 11:44PM UTC"},
+		{name: "format_datetime fraction", query: `.frac | format_datetime("15:04:05.000")`, expected: "23:44:16.125"},
+		{name: "format_datetime date", query: `.created | format_datetime("2006-01-02")`, expected: "2023-01-30"},
+		{name: "tz utc", query: `.local | tz("UTC")`, expected: "2023-03-11T20:00:00Z"},
+		{name: "tz offset", query: `.created | tz("+05:30")`, expected: "2023-01-31T05:14:16+05:30"},
+		{name: "tz zone in winter", query: `"2024-02-28T23:30:00Z" | tz("America/New_York")`, expected: "2024-02-28T18:30:00-05:00"},
+		{name: "tz zone in summer", query: `"2024-07-28T23:30:00Z" | tz("America/New_York")`, expected: "2024-07-28T19:30:00-04:00"},
+		{name: "tz southern zone", query: `"2024-01-15T00:00:00Z" | tz("Australia/Sydney")`, expected: "2024-01-15T11:00:00+11:00"},
+		{name: "tz posix rule", query: `"2024-03-10T07:00:00Z" | tz("EST5EDT,M3.2.0,M11.1.0")`, expected: "2024-03-10T03:00:00-04:00"},
+		{name: "add duration", query: `.created + "1h30m"`, expected: "2023-01-31T01:14:16Z"},
+		{name: "add duration keeps fraction", query: `"2024-12-31T23:59:59.123Z" + "1s"`, expected: "2025-01-01T00:00:00.123Z"},
+		{name: "from_unix fraction", query: "1.5 | from_unix", expected: "1970-01-01T00:00:01.5Z"},
+		{name: "leap day", query: `.day + "24h"`, expected: "2024-02-29T00:00:00Z"},
+		{name: "subtract duration", query: `.created - "48h"`, expected: "2023-01-28T23:44:16Z"},
+		{name: "negative duration keeps offset", query: `.local + "-1.5h"`, expected: "2023-03-12T00:00:00+05:30"},
+		{name: "compound assignment", query: `.created += "10s" | .created`, expected: "2023-01-30T23:44:26Z"},
+		{name: "with_dtf parse", query: `with_dtf("Monday, 02-Jan-06 at 3:04PM MST"; .custom | to_unix)`, expected: "1675122240"},
+		{name: "with_dtf format", query: `with_dtf("Monday, 02-Jan-06 at 3:04PM MST"; .custom + "2h")`, expected: "Tuesday, 31-Jan-23 at 1:44AM UTC"},
+		{name: "with_dtf restored", query: `with_dtf("2006"; .epoch), (.created | to_unix)`, expected: "1675122256\n\n1675122256"},
+		{name: "plain strings still concatenate", query: `"a" + "1h"`, expected: "a1h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+
+	testFile := tester.WriteFile("test.yaml", input)
+	tester.ExecuteFunctionExpectError("_yq_init_temp_dir; yq_parse", `"garbage" | to_unix`, testFile)
+	tester.ExecuteFunctionExpectError("_yq_init_temp_dir; yq_parse", `.created | tz("Mars/Olympus")`, testFile)
+
+	output, err := tester.ExecuteFunction("_yq_init_temp_dir; yq_parse", "now", testFile)
+	if err != nil {
+		t.Fatalf("now failed: %v", err)
+	}
+	if !regexp.MustCompile(`^\d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ$`).MatchString(strings.TrimSpace(output)) {
+		t.Errorf("now printed %q, expected an RFC3339 time", output)
+	}
+}
@@ -0,0 +1,695 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+// awkReadText reads the whole input of an awk program into text, the
+// trail variable tells whether the input ended with a newline
+const awkReadText = `
+    {
+        text = (NR == 1) ? $0 : text "\n" $0
+    }
+    function yq_read_text() {
+        if (trail) text = text "\n"
+        return text
+    }
+`
+
+// GenerateEncoding returns the format and encode operators (@base64, @csv,
+// to_json, from_json, ...), the scalar conversions and the style operator,
+// implemented with awk only
+func GenerateEncoding() string {
+	return `
+# Print 1 when a file ends with a newline, 0 otherwise
+_yq_text_trail() {
+    if [ -s "$1" ] && [ -z "$(tail -c 1 "$1")" ]; then
+        echo 1
+    else
+        echo 0
+    fi
+}
+
+# Encode or format a node: base64, base64d, uri, sh, csv, tsv, json, yaml
+# and props. The result is a string node, except for base64d which decodes
+# to the string that was encoded
+yq_encode() (
+    _format="$1"
+    _file="$2"
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    case "$_format" in
+        json)
+            yq_to_json 0 "$_file"
+            rm -f "$_base"*
+            return
+            ;;
+        yaml)
+            yq_to_yaml "$_file"
+            rm -f "$_base"*
+            return
+            ;;
+        csv|tsv)
+            [ "$_format" = "csv" ] && _sep="," || _sep="$(printf '\t')"
+            _yq_csv_encode "$_sep" "$_file" > "$_base.text" || { rm -f "$_base"*; return 1; }
+            ;;
+        sh)
+            _yq_sh_encode "$_file" > "$_base.text"
+            ;;
+        props)
+            _yq_props "" "$_file" > "$_base.text"
+            ;;
+        base64|base64d|uri)
+            case "$(yq_node_kind "$_file")" in
+                seq|map)
+                    rm -f "$_base"*
+                    _yq_error "cannot encode !!$(yq_node_kind "$_file") as $_format"
+                    return 1
+                    ;;
+            esac
+            yq_scalar_text "$_file" > "$_base.raw"
+            _trail=$(_yq_text_trail "$_base.raw")
+            case "$_format" in
+                base64)
+                    LC_ALL=C awk -v trail="$_trail" '` + awkReadText + `
+                    BEGIN {
+                        for (i = 1; i < 256; i++) ord[sprintf("%c", i)] = i
+                        b64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+                    }
+                    END {
+                        t = yq_read_text()
+                        n = length(t)
+                        out = ""
+                        for (i = 1; i <= n; i += 3) {
+                            c1 = ord[substr(t, i, 1)]
+                            c2 = (i + 1 <= n) ? ord[substr(t, i + 1, 1)] : 0
+                            c3 = (i + 2 <= n) ? ord[substr(t, i + 2, 1)] : 0
+                            v = c1 * 65536 + c2 * 256 + c3
+                            out = out substr(b64, int(v / 262144) + 1, 1) substr(b64, int(v / 4096) % 64 + 1, 1)
+                            out = out ((i + 1 <= n) ? substr(b64, int(v / 64) % 64 + 1, 1) : "=")
+                            out = out ((i + 2 <= n) ? substr(b64, v % 64 + 1, 1) : "=")
+                        }
+                        printf "%s", out
+                    }
+                    ' "$_base.raw" > "$_base.text"
+                    ;;
+                base64d)
+                    LC_ALL=C awk -v trail="$_trail" '` + awkReadText + `
+                    BEGIN {
+                        b64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+                        for (i = 1; i <= 64; i++) val[substr(b64, i, 1)] = i - 1
+                        val["-"] = 62
+                        val["_"] = 63
+                    }
+                    END {
+                        t = yq_read_text()
+                        bits = 0
+                        nbits = 0
+                        for (i = 1; i <= length(t); i++) {
+                            c = substr(t, i, 1)
+                            if (c == "=") break
+                            if (!(c in val)) continue
+                            bits = bits * 64 + val[c]
+                            nbits += 6
+                            if (nbits >= 8) {
+                                nbits -= 8
+                                p = 2 ^ nbits
+                                printf "%c", int(bits / p)
+                                bits = bits % p
+                            }
+                        }
+                    }
+                    ' "$_base.raw" > "$_base.text"
+                    ;;
+                uri)
+                    LC_ALL=C awk -v trail="$_trail" '` + awkReadText + `
+                    BEGIN {
+                        for (i = 1; i < 256; i++) ord[sprintf("%c", i)] = i
+                    }
+                    END {
+                        t = yq_read_text()
+                        out = ""
+                        for (i = 1; i <= length(t); i++) {
+                            c = substr(t, i, 1)
+                            if (c ~ /[A-Za-z0-9_.~-]/) out = out c
+                            else out = out sprintf("%%%02X", ord[c])
+                        }
+                        printf "%s", out
+                    }
+                    ' "$_base.raw" > "$_base.text"
+                    ;;
+            esac
+            ;;
+        *)
+            rm -f "$_base"*
+            _yq_error "unknown format @$_format"
+            return 1
+            ;;
+    esac
+
+    yq_string_scalar "$_base.text"
+    rm -f "$_base"*
+)
+
+# Quote a string for the shell: words made of safe characters are kept as
+# they are, others are single-quoted. Sequences become space-separated words
+_yq_sh_encode() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    case "$(yq_node_kind "$1")" in
+        seq)
+            _count=$(yq_split_items "$1" "$_base")
+            _i=0
+            while [ "$_i" -lt "$_count" ]; do
+                _i=$((_i + 1))
+                yq_scalar_text "$_base.$_i" > "$_base.raw"
+                echo >> "$_base.raw"
+                [ "$_i" -gt 1 ] && printf ' '
+                _yq_sh_word "$_base.raw"
+            done
+            ;;
+        map)
+            rm -f "$_base"*
+            _yq_error "cannot encode !!map as sh"
+            return 1
+            ;;
+        *)
+            yq_scalar_text "$1" > "$_base.raw"
+            echo >> "$_base.raw"
+            _yq_sh_word "$_base.raw"
+            ;;
+    esac
+    rm -f "$_base"*
+)
+
+# Print the text of a file (one trailing newline is dropped) as a shell word
+_yq_sh_word() {
+    awk '` + awkReadText + `
+    END {
+        if (text != "" && text !~ /[^A-Za-z0-9,._+:@%\/-]/) {
+            printf "%s", text
+        } else {
+            gsub(/'"'"'/, "'"'"'\\'"'"''"'"'", text)
+            printf "'"'"'%s'"'"'", text
+        }
+    }
+    ' "$1"
+}
+
+# Print a node as a JSON string node. An indent of 0 gives compact JSON on
+# one line, other indents pretty-print the JSON with that many spaces
+yq_to_json() (
+    _indent="$1"
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    _yq_node_to_json "$2" | awk -v indent="$_indent" '
+    BEGIN {
+        pad = ""
+        for (i = 0; i < indent; i++) pad = pad " "
+    }
+    function nl(level,    s, j) {
+        s = "\n"
+        for (j = 0; j < level; j++) s = s pad
+        return s
+    }
+    {
+        line = $0
+        if (indent == 0) {
+            printf "%s", line
+            next
+        }
+        out = ""
+        level = 0
+        in_str = 0
+        n = length(line)
+        for (i = 1; i <= n; i++) {
+            c = substr(line, i, 1)
+            if (in_str) {
+                out = out c
+                if (c == "\\") {
+                    i++
+                    out = out substr(line, i, 1)
+                } else if (c == "\"") {
+                    in_str = 0
+                }
+            } else if (c == "\"") {
+                in_str = 1
+                out = out c
+            } else if (c == "{" || c == "[") {
+                next_c = substr(line, i + 1, 1)
+                if (next_c == "}" || next_c == "]") {
+                    out = out c next_c
+                    i++
+                } else {
+                    level++
+                    out = out c nl(level)
+                }
+            } else if (c == "}" || c == "]") {
+                level--
+                out = out nl(level) c
+            } else if (c == ",") {
+                out = out c nl(level)
+            } else if (c == ":") {
+                out = out ": "
+            } else {
+                out = out c
+            }
+        }
+        print out
+    }
+    ' > "$_base.text"
+
+    yq_string_scalar "$_base.text"
+    rm -f "$_base"*
+)
+
+# Print a node as a string node holding its YAML text
+yq_to_yaml() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    awk 'NF || started { started = 1; print }' "$1" > "$_base.text"
+    yq_string_scalar "$_base.text"
+    rm -f "$_base"*
+)
+
+# Parse the YAML held by a string node
+yq_from_yaml() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    yq_scalar_text "$1" > "$_base.text"
+    if [ -z "$(tr -d ' \t\n' < "$_base.text")" ]; then
+        echo "null"
+    else
+        awk '{ print }' "$_base.text"
+    fi
+    rm -f "$_base"*
+)
+
+# Parse the JSON held by a string node and print it as block style YAML
+yq_from_json() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    yq_scalar_text "$1" > "$_base.text"
+    LC_ALL=C awk -v errfile="$_base.err" '` + awkUTF8 + awkScalarQuote + `
+    {
+        src = (NR == 1) ? $0 : src "\n" $0
+    }
+    function skip_ws() {
+        while (pos <= len && substr(src, pos, 1) ~ /[ \t\n\r]/) pos++
+    }
+    function fail(msg) {
+        if (!failed) printf "bad JSON: %s", msg > errfile
+        failed = 1
+        pos = len + 1
+    }
+    function parse_string(    s, c, e, cp, lo) {
+        s = ""
+        pos++
+        while (pos <= len) {
+            c = substr(src, pos, 1)
+            if (c == "\"") {
+                pos++
+                return s
+            }
+            if (c == "\\") {
+                e = substr(src, pos + 1, 1)
+                pos += 2
+                if (e == "n") s = s "\n"
+                else if (e == "t") s = s "\t"
+                else if (e == "r") s = s "\r"
+                else if (e == "b") s = s "\b"
+                else if (e == "f") s = s "\f"
+                else if (e == "u") {
+                    cp = yq_hex(substr(src, pos, 4))
+                    pos += 4
+                    if (cp >= 55296 && cp < 56320 && substr(src, pos, 2) == "\\u") {
+                        lo = yq_hex(substr(src, pos + 2, 4))
+                        pos += 6
+                        cp = 65536 + (cp - 55296) * 1024 + (lo - 56320)
+                    }
+                    s = s yq_utf8(cp)
+                }
+                else s = s e
+                continue
+            }
+            s = s c
+            pos++
+        }
+        fail("unterminated string")
+        return s
+    }
+    # Parse a value into node id and return it. Nodes have a type
+    # (str, raw, seq, map), a value and children with their keys
+    function parse_value(    id, c, tok) {
+        skip_ws()
+        id = ++nodes
+        count[id] = 0
+        c = substr(src, pos, 1)
+        if (c == "{") {
+            type[id] = "map"
+            pos++
+            skip_ws()
+            if (substr(src, pos, 1) == "}") {
+                pos++
+                return id
+            }
+            while (pos <= len) {
+                skip_ws()
+                if (substr(src, pos, 1) != "\"") {
+                    fail("expected a key")
+                    return id
+                }
+                count[id]++
+                key[id, count[id]] = parse_string()
+                skip_ws()
+                if (substr(src, pos, 1) != ":") {
+                    fail("expected :")
+                    return id
+                }
+                pos++
+                child[id, count[id]] = parse_value()
+                skip_ws()
+                c = substr(src, pos, 1)
+                pos++
+                if (c == "}") return id
+                if (c != ",") {
+                    fail("expected , or }")
+                    return id
+                }
+            }
+        } else if (c == "[") {
+            type[id] = "seq"
+            pos++
+            skip_ws()
+            if (substr(src, pos, 1) == "]") {
+                pos++
+                return id
+            }
+            while (pos <= len) {
+                count[id]++
+                child[id, count[id]] = parse_value()
+                skip_ws()
+                c = substr(src, pos, 1)
+                pos++
+                if (c == "]") return id
+                if (c != ",") {
+                    fail("expected , or ]")
+                    return id
+                }
+            }
+        } else if (c == "\"") {
+            type[id] = "str"
+            value[id] = parse_string()
+        } else {
+            tok = substr(src, pos)
+            if (match(tok, /^(true|false|null|-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?)/)) {
+                type[id] = "raw"
+                value[id] = substr(tok, 1, RLENGTH)
+                pos += RLENGTH
+            } else {
+                fail("unexpected " (c == "" ? "end of input" : c))
+            }
+        }
+        return id
+    }
+    function inline(id) {
+        if (type[id] == "str") return yq_quote(value[id])
+        if (type[id] == "raw") return value[id]
+        return (type[id] == "seq") ? "[]" : "{}"
+    }
+    function is_block(id) {
+        return (type[id] == "seq" || type[id] == "map") && count[id] > 0
+    }
+    function spaces(n,    s) {
+        s = ""
+        while (n-- > 0) s = s " "
+        return s
+    }
+    # Render a node at an indent, every line ends with a newline
+    function render(id, ind,    out, i, c, sub_out) {
+        if (!is_block(id)) return spaces(ind) inline(id) "\n"
+        out = ""
+        for (i = 1; i <= count[id]; i++) {
+            c = child[id, i]
+            if (type[id] == "map") {
+                if (is_block(c)) out = out spaces(ind) yq_quote(key[id, i]) ":\n" render(c, ind + 2)
+                else out = out spaces(ind) yq_quote(key[id, i]) ": " inline(c) "\n"
+            } else {
+                sub_out = render(c, ind + 2)
+                out = out spaces(ind) "- " substr(sub_out, ind + 3)
+            }
+        }
+        return out
+    }
+    END {
+        len = length(src)
+        pos = 1
+        skip_ws()
+        if (pos > len) {
+            print "null"
+            exit
+        }
+        root = parse_value()
+        skip_ws()
+        if (!failed && pos <= len) fail("trailing data")
+        if (failed) exit 1
+        printf "%s", render(root, 0)
+    }
+    ' "$_base.text"
+    if [ -s "$_base.err" ]; then
+        _yq_error "$(cat "$_base.err")"
+        rm -f "$_base"*
+        return 1
+    fi
+    rm -f "$_base"*
+)
+
+# Convert a scalar to a number: numeric strings lose their quotes, hex (0x1F)
+# and octal (0o17) ones become decimal, anything else is an error
+yq_to_number() {
+    if [ "$(yq_node_kind "$1")" != "scalar" ]; then
+        _yq_error "cannot convert !!$(yq_node_kind "$1") to a number"
+        return 1
+    fi
+    _num_text=$(yq_scalar_text "$1")
+    if printf '%s\n' "$_num_text" | grep -q '^0[xX][0-9a-fA-F][0-9a-fA-F]*$\|^0o[0-7][0-7]*$'; then
+        # Convert digit by digit into decimal digits so big values stay exact
+        printf '%s\n' "$_num_text" | awk '{
+            base = ($0 ~ /^0[xX]/) ? 16 : 8
+            n = 1
+            d[1] = 0
+            for (i = 3; i <= length($0); i++) {
+                carry = index("0123456789abcdef", tolower(substr($0, i, 1))) - 1
+                for (k = 1; k <= n; k++) {
+                    v = d[k] * base + carry
+                    d[k] = v % 10
+                    carry = int(v / 10)
+                }
+                while (carry) {
+                    d[++n] = carry % 10
+                    carry = int(carry / 10)
+                }
+            }
+            for (k = n; k >= 1; k--) printf "%d", d[k]
+            print ""
+        }'
+    elif printf '%s\n' "$_num_text" | grep -q '^[-+]\{0,1\}\([0-9][0-9_]*\(\.[0-9]*\)\{0,1\}\|\.[0-9][0-9]*\)\([eE][-+]\{0,1\}[0-9][0-9]*\)\{0,1\}$'; then
+        printf '%s\n' "${_num_text#+}"
+    else
+        _yq_error "cannot convert \"$_num_text\" to a number"
+    fi
+}
+
+# Convert a node to a string: scalars keep their text, collections become
+# their YAML text
+yq_to_string() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    if [ "$(yq_node_kind "$1")" = "scalar" ]; then
+        yq_scalar_text "$1" > "$_base.text"
+    else
+        printf '%s' "$(awk 'NF || started { started = 1; print }' "$1")" > "$_base.text"
+    fi
+    yq_string_scalar "$_base.text"
+    rm -f "$_base"*
+)
+
+# Convert a scalar to a boolean: true/false, yes/no, on/off and 1/0
+yq_to_bool() {
+    case "$(yq_scalar_text "$1" | tr 'A-Z' 'a-z')" in
+        true|yes|on|y|1) echo "true" ;;
+        false|no|off|n|0) echo "false" ;;
+        *)
+            _yq_error "cannot convert \"$(yq_scalar_text "$1")\" to a boolean"
+            ;;
+    esac
+}
+
+# Print the style of a node: double, single, literal, folded, flow or an
+# empty string for plain scalars and block collections
+yq_style() {
+    _style=$(awk '
+    /^[[:space:]]*(#.*)?$/ { next }
+    {
+        sub(/^[[:space:]]*/, "")
+        c = substr($0, 1, 1)
+        if (c == "\"") print "double"
+        else if (c == "'"'"'") print "single"
+        else if (c == "|") print "literal"
+        else if (c == ">") print "folded"
+        else if (c == "[" || c == "{") print "flow"
+        exit
+    }
+    ' "$1")
+    if [ -n "$_style" ]; then
+        echo "$_style"
+    else
+        echo '""'
+    fi
+}
+
+# Rewrite a node in a style: scalars are requoted (double, single) or
+# written as block scalars (literal, folded), "" gives the plain form where
+# it reads back the same and flow writes collections on one line
+yq_restyle() (
+    _style="$1"
+    _file="$2"
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    _kind=$(yq_node_kind "$_file")
+    _tag=$(yq_tag "$_file")
+    if [ "$_tag" = "!!seq" ] || [ "$_tag" = "!!map" ]; then
+        # Flow collections read as scalars, they are already on one line
+        if [ "$_style" = "flow" ] && [ "$_kind" != "scalar" ]; then
+            _yq_flow "$_file"
+        else
+            cat "$_file"
+        fi
+        rm -f "$_base"*
+        return
+    fi
+
+    yq_scalar_text "$_file" > "$_base.text"
+    _trail=$(_yq_text_trail "$_base.text")
+    case "$_style" in
+        double|single|literal|folded)
+            awk -v style="$_style" -v trail="$_trail" '` + awkReadText + `
+            END {
+                t = yq_read_text()
+                if (style == "single" && index(t, "\n")) style = "double"
+                if ((style == "literal" || style == "folded") && index(t, "\n") == 0 && t != "") {
+                    printf "%s-\n  %s\n", (style == "literal" ? "|" : ">"), t
+                } else if (style == "literal" || style == "folded") {
+                    # Folded blocks keep their line breaks as blank lines
+                    chomp = trail ? "" : "-"
+                    n = split(text, lines, "\n")
+                    printf "%s%s\n", (style == "literal" ? "|" : ">"), chomp
+                    for (i = 1; i <= n; i++) {
+                        if (style == "folded" && i > 1) print ""
+                        print (lines[i] == "" ? "" : "  " lines[i])
+                    }
+                } else if (style == "single") {
+                    gsub(/'"'"'/, "'"'"''"'"'", t)
+                    print "'"'"'" t "'"'"'"
+                } else {
+                    gsub(/\\/, "\\\\\\\\", t)
+                    gsub(/"/, "\\\"", t)
+                    gsub(/\t/, "\\t", t)
+                    gsub(/\r/, "\\r", t)
+                    gsub(/\n/, "\\n", t)
+                    print "\"" t "\""
+                }
+            }
+            ' "$_base.text"
+            ;;
+        *)
+            # Plain where possible, quoted strings keep reading as strings
+            case "$(yq_tag "$_file")" in
+                "!!str") yq_string_scalar "$_base.text" ;;
+                *) printf '%s\n' "$(cat "$_base.text")" ;;
+            esac
+            ;;
+    esac
+    rm -f "$_base"*
+)
+
+# Print a collection in flow style on one line ([a, b], {a: 1}), nested
+# collections included
+_yq_flow() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _kind=$(yq_node_kind "$1")
+    case "$_kind" in
+        seq|map)
+            _count=$(yq_split_items "$1" "$_base")
+            [ "$_kind" = "map" ] && yq_map_keys "$1" > "$_base.keys"
+            _out=""
+            _i=1
+            while [ "$_i" -le "$_count" ]; do
+                _item=$(_yq_flow "$_base.$_i")
+                if [ "$_kind" = "map" ]; then
+                    printf '%s' "$(sed -n "${_i}p" "$_base.keys")" > "$_base.key"
+                    _item="$(yq_string_scalar "$_base.key"): $_item"
+                fi
+                _out="${_out:+$_out, }$_item"
+                _i=$((_i + 1))
+            done
+            if [ "$_kind" = "map" ]; then
+                printf '{%s}\n' "$_out"
+            else
+                printf '[%s]\n' "$_out"
+            fi
+            ;;
+        *)
+            if [ "$(yq_style "$1")" = "literal" ] || [ "$(yq_style "$1")" = "folded" ]; then
+                yq_restyle double "$1"
+            else
+                awk 'NF { sub(/^[[:space:]]+/, ""); print; exit }' "$1"
+            fi
+            ;;
+    esac
+    rm -f "$_base"*
+)
+
+# PATH style="STYLE": restyle every node the path matches. With .. every
+# node of the document is restyled, children before their parents
+yq_set_style() (
+    _lhs="$1"
+    _rhs="$2"
+    _file="$3"
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    _yq_parse_result "$_rhs" "$_file" > "$_base.style"
+    _style=$(yq_scalar_text "$_base.style")
+    case "$_style" in
+        double|single|literal|folded|flow|"") ;;
+        *)
+            rm -f "$_base"*
+            _yq_error "unknown style \"$_style\""
+            return 1
+            ;;
+    esac
+
+    if [ "$_lhs" = ".." ]; then
+        { echo "."; _yq_all_paths "$_file" ""; } > "$_base.paths"
+    else
+        yq_expand_path "$_lhs" "$_file" > "$_base.paths" || printf '%s\n' "$_lhs" > "$_base.paths"
+    fi
+    awk '{ lines[NR] = $0 } END { for (i = NR; i > 0; i--) print lines[i] }' "$_base.paths" > "$_base.order"
+
+    cp "$_file" "$_base.doc"
+    while IFS= read -r _target || [ -n "$_target" ]; do
+        _yq_node_at "$_target" "$_base.doc" > "$_base.node"
+        yq_restyle "$_style" "$_base.node" > "$_base.new"
+        yq_set_path "$_target" "$_base.new" "$_base.doc" > "$_base.next"
+        mv "$_base.next" "$_base.doc"
+    done < "$_base.order"
+    cat "$_base.doc"
+    rm -f "$_base"*
+)
+`
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import "testing"
+
+func TestYqEncode(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "s: \"hi there\"\nq: \"it's\"\nu: \"a b/c?\"\nn: 3\n" +
+		"args:\n  - plain\n  - two words\n" +
+		"rows:\n  - - a\n    - \"b,c\"\n  - - 'say \"x\"'\n    - \n" +
+		"m:\n  x: 1\n  y:\n    - true\n" +
+		"text: |\n  line one\n  line two\n" +
+		"j: '{\"a\":[1,{\"b\":\"\\u00e9\"}],\"c\":{},\"d\":\"true\"}'"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "base64", query: ".s | @base64", expected: "aGkgdGhlcmU="},
+		{name: "base64 padding", query: ".n | @base64", expected: "Mw=="},
+		{name: "base64 block scalar", query: ".text | @base64", expected: "bGluZSBvbmUKbGluZSB0d28K"},
+		{name: "base64d", query: ".s | @base64 | @base64d", expected: "hi there"},
+		{name: "base64d block scalar", query: ".text | @base64 | @base64d", expected: "|\n  line one\n  line two"},
+		{name: "uri", query: ".u | @uri", expected: "a%20b%2Fc%3F"},
+		{name: "sh plain word", query: ".n | @sh", expected: "\"3\""},
+		{name: "sh quote", query: ".q | @sh", expected: "\"'it'\\\\''s'\""},
+		{name: "sh array", query: ".args | @sh", expected: "plain 'two words'"},
+		{name: "csv rows", query: ".rows | @csv", expected: "|-\n  a,\"b,c\"\n  \"say \"\"x\"\"\","},
+		{name: "tsv row", query: ".args | @tsv", expected: "\"plain\\ttwo words\""},
+		{name: "json compact", query: ".m | @json", expected: `"{\"x\":1,\"y\":[true]}"`},
+		{name: "to_json", query: ".m | to_json", expected: "|\n  {\n    \"x\": 1,\n    \"y\": [\n      true\n    ]\n  }"},
+		{name: "to_json indent", query: ".m | to_json(0)", expected: `"{\"x\":1,\"y\":[true]}"`},
+		{name: "to_yaml", query: ".m | to_yaml", expected: "|\n  x: 1\n  y:\n    - true"},
+		{name: "from_yaml", query: ".m | to_yaml | from_yaml | .y[0]", expected: "true"},
+		{name: "props", query: ".m | @props", expected: "|\n  x = 1\n  y.0 = true"},
+		{name: "from_json", query: ".j | from_json", expected: "a:\n  - 1\n  - b: é\nc: {}\nd: \"true\""},
+		{name: "from_json access", query: ".j | from_json | .a[1].b", expected: "é"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+func TestYqEncodeErrors(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "m:\n  x: 1\nbad: '{\"a\":'"
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "unknown format", query: ".m | @nope"},
+		{name: "base64 of a map", query: ".m | @base64"},
+		{name: "csv of a map", query: ".m | @csv"},
+		{name: "invalid json", query: ".bad | from_json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpectError("_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+func TestYqConversions(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "port: \"8080\"\nn: 3\ns: plain\nflag: \"yes\"\nitems:\n  - a\n  - b"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "to_number", query: ".port | to_number", expected: "8080"},
+		{name: "to_number keeps numbers", query: ".n | to_number", expected: "3"},
+		{name: "to_number hex", query: `"0x1F" | to_number`, expected: "31"},
+		{name: "to_number big hex", query: `"0xFFFFFFFFFFFFFFFFFF" | to_number`, expected: "4722366482869645213695"},
+		{name: "to_number octal", query: `"0o17" | to_number`, expected: "15"},
+		{name: "to_string", query: ".n | to_string", expected: `"3"`},
+		{name: "tostring", query: ".s | tostring", expected: "plain"},
+		{name: "to_string collection", query: ".items | to_string", expected: "|-\n  - a\n  - b"},
+		{name: "to_bool", query: ".flag | to_bool", expected: "true"},
+		{name: "tojson scalar", query: ".s | tojson", expected: `"\"plain\""`},
+		{name: "tojson number", query: ".n | tojson", expected: `"3"`},
+		{name: "assign converted", query: ".port = (.port | to_number) | .port", expected: "8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+
+	testFile := tester.WriteFile("test.yaml", input)
+	tester.ExecuteFunctionExpectError("_yq_init_temp_dir; yq_parse", ".s | to_number", testFile)
+	tester.ExecuteFunctionExpectError("_yq_init_temp_dir; yq_parse", ".s | to_bool", testFile)
+}
+
+func TestYqStyle(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "s: plain text\nq: 'single'\nd: \"double\"\nlit: |\n  one\n  two\nitems:\n  - a\n  - b: 1"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "plain", query: ".s | style", expected: `""`},
+		{name: "single", query: ".q | style", expected: "single"},
+		{name: "double", query: ".d | style", expected: "double"},
+		{name: "literal", query: ".lit | style", expected: "literal"},
+		{name: "set double", query: `.s style="double" | .s | style`, expected: "double"},
+		{name: "set single", query: `.d style="single" | .d | style`, expected: "single"},
+		{name: "set plain", query: `.q style="" | .q`, expected: "single"},
+		{name: "set literal", query: `.s style="literal" | .s`, expected: "|-\n  plain text"},
+		{name: "literal to double", query: `.lit style="double" | .lit`, expected: `"one\ntwo\n"`},
+		{name: "flow", query: `.items style="flow" | .items`, expected: "[a, {b: 1}]"},
+		{name: "update with style", query: `.s |= style="single" | .s`, expected: "'plain text'"},
+		{name: "every node", query: `.. style="flow"`, expected: `{s: plain text, q: single, d: double, lit: "one\ntwo\n", items: [a, {b: 1}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+
+	testFile := tester.WriteFile("test.yaml", input)
+	tester.ExecuteFunctionExpectError("_yq_init_temp_dir; yq_parse", `.s style="fancy"`, testFile)
+}
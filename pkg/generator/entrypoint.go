@@ -24,31 +24,50 @@ _yq_init_temp_dir
 # Main entry point
 _exit_on_null=0
 _output_format="yaml"
+_input_format="yaml"
+_csv_auto_parse=1
 _raw_output=0
+_unwrap_scalar=1
 _indent_level=2
+_pretty_print=0
+_eval_all=0
 
 # Skip yq subcommand if present (e.g., "yq e -o=j" has 'e' as subcommand)
-if [ "$1" = "e" ] || [ "$1" = "eval" ] || [ "$1" = "select" ] || [ "$1" = "empty" ]; then
+# Filters such as empty or select are queries, not subcommands
+if [ "$1" = "e" ] || [ "$1" = "eval" ]; then
+    shift
+elif [ "$1" = "ea" ] || [ "$1" = "eval-all" ]; then
+    # eval-all evaluates the query once over the documents of all files
+    _eval_all=1
     shift
 fi
 
 # Display help message
 _show_help() {
-    printf "Usage: yq [OPTIONS] QUERY [FILE]\n"
+    printf "Usage: yq [eval|eval-all] [OPTIONS] QUERY [FILE...]\n"
     printf "\n"
     printf "A POSIX-compliant implementation of yq for querying YAML and JSON data.\n"
     printf "\n"
     printf "ARGUMENTS:\n"
     printf "  QUERY              YQ query expression (required)\n"
     printf "  FILE               Input file (optional, reads from stdin if not provided)\n"
+    printf "                     eval-all (ea) reads the documents of every FILE as one stream\n"
     printf "\n"
     printf "OPTIONS:\n"
     printf "  -e, --error-mode   Exit with code 5 if result is empty or null\n"
-    printf "  -r, --raw-output   Output raw strings without quotes\n"
-    printf "  -o, --output FMT   Set output format: yaml (default) or json/j\n"
+    printf "  -r, --raw-output   Output raw strings without quotes (also for JSON output)\n"
+    printf "  --unwrapScalar=false  Keep the quotes and style of scalar results\n"
+    printf "  -o, --output FMT   Set output format: yaml (default), json/j, props/p, csv/c, tsv/t or shell/sh\n"
     printf "  -o=FMT             Short form of --output\n"
+    printf "  -p, --input-format FMT  Set input format: yaml (default), props/p, csv/c or tsv/t\n"
+    printf "  --csv-auto-parse=false  Read every CSV/TSV field as a string\n"
+    printf "  --properties-separator SEP  Separator of props output (default: \" = \")\n"
+    printf "  --properties-array-brackets  Write sequence indices of props output as [0]\n"
+    printf "  --shell-prefix P   Prefix of the variable names of shell output\n"
+    printf "  --shell-key-separator SEP  Separator of the keys in shell variable names (default: _)\n"
     printf "  -I, --indent N     Set indentation level (default: 2)\n"
-    printf "  -I=N               Short form of --indent\n"
+    printf "  -IN, -I=N, --indent=N  Short and joined forms of --indent\n"
+    printf "  -P, --prettyPrint  Write flow collections as blocks and drop needless quotes\n"
     printf "  -j, --json         Shorthand for -o=json\n"
     printf "  -h, --help         Display this help message\n"
     printf "\n"
@@ -58,6 +77,8 @@ _show_help() {
     printf "  yq -r '.name' data.yaml              Output raw string\n"
     printf "  yq -o=json '.files[]' config.yaml    Output as JSON\n"
     printf "  echo '{key: value}' | yq '.key'     Read from stdin\n"
+    printf "  yq ea '. as \$i ireduce ({}; . * \$i)' a.yaml b.yaml   Deep merge files\n"
+    printf "  eval \"\$(yq -o shell . config.yaml)\"   Load a config as shell variables\n"
     printf "\n"
     printf "For more information, visit: https://github.com/alexandremahdhaoui/posix-yq\n"
 }
@@ -79,8 +100,14 @@ while [ $# -gt 0 ]; do
             _exit_on_null=1
             shift
             ;;
-        -r|--raw-output)
+        -r|--raw-output|--unwrapScalar|--unwrapScalar=true)
             _raw_output=1
+            _unwrap_scalar=1
+            shift
+            ;;
+        --unwrapScalar=false)
+            _raw_output=0
+            _unwrap_scalar=0
             shift
             ;;
         -o|--output)
@@ -91,6 +118,50 @@ while [ $# -gt 0 ]; do
             _output_format="${1#-o=}"
             shift
             ;;
+        -p|--input-format)
+            _input_format="$2"
+            shift 2
+            ;;
+        -p=*)
+            _input_format="${1#-p=}"
+            shift
+            ;;
+        --csv-auto-parse|--csv-auto-parse=true|--tsv-auto-parse|--tsv-auto-parse=true)
+            _csv_auto_parse=1
+            shift
+            ;;
+        --csv-auto-parse=false|--tsv-auto-parse=false)
+            _csv_auto_parse=0
+            shift
+            ;;
+        --shell-key-separator)
+            _yq_shell_separator="$2"
+            shift 2
+            ;;
+        --shell-key-separator=*)
+            _yq_shell_separator="${1#--shell-key-separator=}"
+            shift
+            ;;
+        --shell-prefix)
+            _yq_shell_prefix="$2"
+            shift 2
+            ;;
+        --shell-prefix=*)
+            _yq_shell_prefix="${1#--shell-prefix=}"
+            shift
+            ;;
+        --properties-separator)
+            _yq_props_separator="$2"
+            shift 2
+            ;;
+        --properties-separator=*)
+            _yq_props_separator="${1#--properties-separator=}"
+            shift
+            ;;
+        --properties-array-brackets)
+            _yq_props_brackets=1
+            shift
+            ;;
         -I|--indent)
             _indent_level="$2"
             shift 2
@@ -99,6 +170,18 @@ while [ $# -gt 0 ]; do
             _indent_level="${1#-I=}"
             shift
             ;;
+        --indent=*)
+            _indent_level="${1#--indent=}"
+            shift
+            ;;
+        -I*)
+            _indent_level="${1#-I}"
+            shift
+            ;;
+        -P|--prettyPrint)
+            _pretty_print=1
+            shift
+            ;;
         -j|--json)
             _output_format="json"
             shift
@@ -117,10 +200,32 @@ while [ $# -gt 0 ]; do
     esac
 done
 
+case "$_indent_level" in
+    ""|*[!0-9]*)
+        >&2 echo "Error: invalid indent \"$_indent_level\""
+        exit 1
+        ;;
+esac
+
 # First positional argument is the query
 QUERY="$1"
 FILE="$2"
 
+# eval-all splits every file into its documents, the query then runs once
+# over all of them with the file index, file name and line offset of each
+if [ "$_eval_all" -eq 1 ] && [ $# -ge 2 ]; then
+    shift
+    _eval_first_file="$1"
+    for _doc_file in "$@"; do
+        if [ ! -f "$_doc_file" ]; then
+            >&2 echo "Error: open $_doc_file: no such file or directory"
+            exit 1
+        fi
+    done
+    _eval_docs=$(mktemp -p "$_YQ_TEMP_DIR")
+    yq_split_documents "$_eval_docs" "$@"
+fi
+
 # If no file provided, read from stdin
 if [ -z "$FILE" ]; then
     # Check if stdin has content
@@ -146,36 +251,82 @@ if [ -z "$FILE" ]; then
     fi
 fi
 
+# Other input formats are converted to YAML before the query runs
+case "$_input_format" in
+    props|p|properties)
+        if [ ! -f "$FILE" ]; then
+            >&2 echo "Error: open $FILE: no such file or directory"
+            exit 1
+        fi
+        _converted_file=$(mktemp -p "$_YQ_TEMP_DIR")
+        yq_props_to_yaml "$FILE" > "$_converted_file"
+        FILE="$_converted_file"
+        ;;
+    csv|c|tsv|t)
+        if [ ! -f "$FILE" ]; then
+            >&2 echo "Error: open $FILE: no such file or directory"
+            exit 1
+        fi
+        case "$_input_format" in
+            csv|c) _csv_sep="," ;;
+            *) _csv_sep=$(printf '\t') ;;
+        esac
+        _converted_file=$(mktemp -p "$_YQ_TEMP_DIR")
+        yq_csv_to_yaml "$_csv_sep" "$_csv_auto_parse" "$FILE" > "$_converted_file"
+        FILE="$_converted_file"
+        ;;
+esac
+
+# filename, fileIndex and line of the input; eval-all sets them for each
+# document
+_yq_filename="${_eval_first_file:-$FILE}"
+[ -n "$_cleanup_file" ] && [ -z "$_eval_first_file" ] && _yq_filename="-"
+_yq_file_index=0
+_yq_line_offset=0
+
 # Execute the query
-_result=$(yq_parse "$QUERY" "$FILE")
+if [ -n "$_eval_docs" ]; then
+    _result=$(yq_eval_all "$QUERY" "$_eval_docs")
+else
+    _result=$(yq_parse "$QUERY" "$FILE")
+fi
 _exit_code=$?
+# Errors raised inside pipes and iterations are recorded rather than
+# returned. An error aborts the evaluation: nothing is written to stdout
+if _yq_raised; then
+    [ -n "$_cleanup_file" ] && rm -f "$_cleanup_file"
+    exit 1
+fi
 
 # Cleanup temporary file if created
 if [ -n "$_cleanup_file" ]; then
     rm -f "$_cleanup_file"
 fi
 
-# Handle -r (raw output) flag
-if [ $_raw_output -eq 1 ]; then
-    # Raw output mode - output values without quotes
-    _result=$(yq_unquote "$_result")
-else
-    # Normal mode - unquote only for simple string values
-    # For structured data (arrays/objects), keep YAML formatting
-    _result=$(yq_unquote "$_result")
-fi
-
-# Handle JSON output format (must be before cleanup to preserve object separators)
+# Handle JSON output format, string results keep their JSON quotes unless
+# -r is given
 if [ "$_output_format" = "json" ] || [ "$_output_format" = "j" ]; then
-    # Convert YAML output to JSON
     # The function handles grouping of multi-line blocks separated by blank lines
     _result=$(yq_yaml_to_json "$_result")
+    if [ "$_raw_output" -eq 1 ]; then
+        # One JSON value per line, strings are printed as their raw text
+        _result=$(printf '%s\n' "$_result" | while IFS= read -r _json_line; do
+            yq_unquote "$_json_line"
+        done)
+    fi
+elif [ "$_output_format" = "props" ] || [ "$_output_format" = "p" ] || [ "$_output_format" = "properties" ]; then
+    _result=$(yq_yaml_to_props "$_result")
+elif [ "$_output_format" = "csv" ] || [ "$_output_format" = "c" ]; then
+    _result=$(yq_yaml_to_csv "," "$_result") || _exit_code=1
+elif [ "$_output_format" = "tsv" ] || [ "$_output_format" = "t" ]; then
+    _result=$(yq_yaml_to_csv "$(printf '\t')" "$_result") || _exit_code=1
+elif [ "$_output_format" = "shell" ] || [ "$_output_format" = "sh" ] || [ "$_output_format" = "s" ]; then
+    _result=$(yq_yaml_to_shell "$_result")
 else
-    # Clean up result: remove blank line separators from array iteration
-    # The iteration uses blank lines as separators, but we only want actual content
-    while printf '%s' "$_result" | grep -q '^[[:space:]]*$'; do
-        _result=$(printf '%s\n' "$_result" | grep -v '^[[:space:]]*$')
-    done
+    # Results are written again with the -I indentation, blank line
+    # separators from iteration are dropped. Scalars are unwrapped to their
+    # text unless --unwrapScalar=false keeps their quotes and style
+    _result=$(yq_yaml_emit "$_result" "$_indent_level" "$_pretty_print" "$_unwrap_scalar")
 fi
 
 # Output result (preserve newlines from multiline results)
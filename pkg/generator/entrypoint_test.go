@@ -60,6 +60,12 @@ func TestGenerateEntryPointHandlesSubcommands(t *testing.T) {
 			t.Errorf("EntryPoint subcommand handling missing '%s'", test)
 		}
 	}
+
+	for _, filter := range []string{"empty", "select"} {
+		if strings.Contains(result, "[ \"$1\" = \""+filter+"\" ]") {
+			t.Errorf("EntryPoint treats the %s filter as a subcommand", filter)
+		}
+	}
 }
 
 // TestGenerateEntryPointParsesFlagsExit verifies -e flag parsing
@@ -108,6 +114,9 @@ func TestGenerateEntryPointParsesFlagsIndent(t *testing.T) {
 	if !strings.Contains(result, "_indent_level") {
 		t.Error("EntryPoint missing indentation level handling")
 	}
+	if !strings.Contains(result, "--indent=*)") || !strings.Contains(result, "-I*)") {
+		t.Error("EntryPoint missing --indent=N and -IN forms")
+	}
 }
 
 // TestGenerateEntryPointParsesFlagsJSON verifies -j flag parsing
@@ -294,3 +303,22 @@ func TestGenerateEntryPointHelpIncludesExamples(t *testing.T) {
 		t.Error("Help text missing array iteration example")
 	}
 }
+
+// TestGenerateEntryPointHandlesEvalAll verifies eval-all streams every file
+func TestGenerateEntryPointHandlesEvalAll(t *testing.T) {
+	result := GenerateEntryPoint()
+
+	tests := []string{
+		"\"eval-all\"",       // eval-all subcommand
+		"\"ea\"",             // eval-all short form
+		"_eval_all=1",        // Stream mode flag
+		"yq_split_documents", // Documents of every file
+		"yq_eval_all",        // One evaluation over all documents
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(result, test) {
+			t.Errorf("EntryPoint eval-all handling missing '%s'", test)
+		}
+	}
+}
@@ -124,6 +124,106 @@ func TestGenerateJSON(t *testing.T) {
 	}
 }
 
+// TestGenerateYAML verifies the YAML serializer is generated
+func TestGenerateYAML(t *testing.T) {
+	result := GenerateYAML()
+
+	tests := []string{
+		"yq_yaml_emit()",
+		"_yq_node_to_yaml()",
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(result, test) {
+			t.Errorf("GenerateYAML missing '%s'", test)
+		}
+	}
+}
+
+// TestGenerateProperties verifies the properties conversions are generated
+func TestGenerateProperties(t *testing.T) {
+	result := GenerateProperties()
+
+	tests := []string{
+		"yq_yaml_to_props()",
+		"yq_props_to_yaml()",
+		"_yq_props()",
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(result, test) {
+			t.Errorf("GenerateProperties missing '%s'", test)
+		}
+	}
+}
+
+// TestGenerateCSV verifies the CSV and TSV conversions are generated
+func TestGenerateCSV(t *testing.T) {
+	result := GenerateCSV()
+
+	tests := []string{
+		"yq_yaml_to_csv()",
+		"yq_csv_to_yaml()",
+		"_yq_csv_encode()",
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(result, test) {
+			t.Errorf("GenerateCSV missing '%s'", test)
+		}
+	}
+}
+
+// TestGenerateShellOutput verifies the shell variable output is generated
+func TestGenerateShellOutput(t *testing.T) {
+	result := GenerateShellOutput()
+
+	tests := []string{
+		"yq_yaml_to_shell()",
+		"_yq_shell_vars()",
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(result, test) {
+			t.Errorf("GenerateShellOutput missing '%s'", test)
+		}
+	}
+}
+
+// TestGenerateEncoding verifies the format and encode operators are generated
+func TestGenerateEncoding(t *testing.T) {
+	result := GenerateEncoding()
+
+	tests := []string{
+		"yq_encode()",
+		"yq_to_json()",
+		"yq_from_json()",
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(result, test) {
+			t.Errorf("GenerateEncoding missing '%s'", test)
+		}
+	}
+}
+
+// TestGenerateDatetime verifies the date and time operators are generated
+func TestGenerateDatetime(t *testing.T) {
+	result := GenerateDatetime()
+
+	tests := []string{
+		"yq_datetime()",
+		"yq_now()",
+		"yq_with_dtf()",
+	}
+
+	for _, test := range tests {
+		if !strings.Contains(result, test) {
+			t.Errorf("GenerateDatetime missing '%s'", test)
+		}
+	}
+}
+
 // TestGenerateEntryPoint verifies the main entry point is generated
 func TestGenerateEntryPoint(t *testing.T) {
 	result := GenerateEntryPoint()
@@ -150,6 +250,12 @@ func TestConcatenation(t *testing.T) {
 		GenerateAdvancedFunctions(),
 		GenerateOperators(),
 		GenerateJSON(),
+		GenerateYAML(),
+		GenerateProperties(),
+		GenerateCSV(),
+		GenerateShellOutput(),
+		GenerateEncoding(),
+		GenerateDatetime(),
 		GenerateEntryPoint(),
 	}
 
@@ -173,6 +279,12 @@ func TestConcatenation(t *testing.T) {
 		"yq_assign",
 		"yq_del",
 		"yq_yaml_to_json",
+		"yq_yaml_emit",
+		"yq_yaml_to_props",
+		"yq_props_to_yaml",
+		"yq_yaml_to_csv",
+		"yq_csv_to_yaml",
+		"yq_yaml_to_shell",
 	}
 
 	for _, fn := range requiredFunctions {
@@ -191,6 +303,12 @@ func TestOutputFormats(t *testing.T) {
 		"GenerateAdvancedFunctions": GenerateAdvancedFunctions,
 		"GenerateOperators":         GenerateOperators,
 		"GenerateJSON":              GenerateJSON,
+		"GenerateYAML":              GenerateYAML,
+		"GenerateProperties":        GenerateProperties,
+		"GenerateCSV":               GenerateCSV,
+		"GenerateShellOutput":       GenerateShellOutput,
+		"GenerateEncoding":          GenerateEncoding,
+		"GenerateDatetime":          GenerateDatetime,
 		"GenerateEntryPoint":        GenerateEntryPoint,
 	}
 
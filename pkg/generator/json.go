@@ -19,76 +19,187 @@ package generator
 func GenerateJSON() string {
 	return `
 # Convert YAML output to JSON format
-# Input: Multi-line YAML formatted as "key: value" pairs from iteration
-# Output: One or more JSON objects, one per line
+# Input: one or more YAML results separated by blank lines (iteration, comma)
+# Output: one JSON value per result, one per line
 yq_yaml_to_json() {
     _yaml_input="$1"
 
-    # Use AWK to detect object boundaries
-    # When we see a key we'\''ve seen before at depth 0, it means a new object is starting
-    printf '%s\n' "$_yaml_input" | awk '
-    BEGIN {
-        first = 1  # First property in current object
-        obj_started = 0
-    }
-    /^[[:space:]]*$/ {
-        # Empty line - skip but dont close object (we use key repetition for boundaries)
-        next
-    }
-    /^[^[:space:]]/ {
-        # Line starts at column 0, not indented - a top-level property
-        if ($0 ~ /^[^:]+:[[:space:]]*/) {
-            # Extract the key
-            idx = index($0, ":")
-            key = substr($0, 1, idx - 1)
+    # Split the results into files, blank lines inside block scalars are kept
+    _json_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _json_results=$(mktemp -p "$_YQ_TEMP_DIR")
+    printf '%s\n' "$_yaml_input" > "$_json_results"
+    _json_count=$(yq_split_results "$_json_results" "$_json_base")
+    rm -f "$_json_results"
 
-            # Check if we'\''ve seen this key in the current object
-            # If yes and we'\''ve started an object, close the current one and start new
-            if (current_obj_keys[key] == 1 && obj_started == 1) {
-                printf "}\n"
-                first = 1
-                # Reset the object keys tracking for the new object
-                for (k in current_obj_keys) delete current_obj_keys[k]
-            }
-
-            # Mark this key as seen in the current object
-            current_obj_keys[key] = 1
+    _json_i=0
+    while [ "$_json_i" -lt "$_json_count" ]; do
+        _json_i=$((_json_i + 1))
+        _yq_node_to_json "$_json_base.$_json_i"
+        rm -f "$_json_base.$_json_i"
+    done
+    rm -f "$_json_base"
+}
 
-            # Start new object if needed
-            if (first == 1) {
-                printf "{"
-                first = 0
-                obj_started = 1
-            } else {
-                printf ","
-            }
+# Convert a single YAML node (file) to one line of JSON
+_yq_node_to_json() {
+    case "$(yq_node_kind "$1")" in
+        seq)
+            # Items are converted in subshells so recursion keeps its own state
+            _node_base=$(mktemp -p "$_YQ_TEMP_DIR")
+            _node_count=$(yq_split_items "$1" "$_node_base")
+            printf "["
+            _node_i=0
+            while [ "$_node_i" -lt "$_node_count" ]; do
+                _node_i=$((_node_i + 1))
+                [ "$_node_i" -gt 1 ] && printf ","
+                printf "%s" "$(_yq_node_to_json "$_node_base.$_node_i")"
+                rm -f "$_node_base.$_node_i"
+            done
+            printf "]\n"
+            rm -f "$_node_base"
+            ;;
+        map)
+            _yq_map_to_json "$1"
+            ;;
+        *)
+            if head -n 1 "$1" | grep -q '^[|>][-+0-9]*[[:space:]]*$'; then
+                _yq_json_string "$1"
+                printf "\n"
+            else
+                _yq_scalar_to_json "$1"
+            fi
+            ;;
+    esac
+}
 
-            # Extract value (after the colon)
-            value = substr($0, idx + 1)
+# Convert a block mapping to a JSON object, nested nodes are converted
+# recursively and scalar values keep their JSON type
+_yq_map_to_json() {
+    _map_json_keys=$(mktemp -p "$_YQ_TEMP_DIR")
+    _map_json_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    yq_map_keys "$1" > "$_map_json_keys"
+    yq_split_items "$1" "$_map_json_base" > /dev/null
 
-            # Trim whitespace from value
-            gsub(/^[[:space:]]+|[[:space:]]+$/, "", value)
+    printf "{"
+    _map_json_i=0
+    while IFS= read -r _map_json_key || [ -n "$_map_json_key" ]; do
+        _map_json_i=$((_map_json_i + 1))
+        [ "$_map_json_i" -gt 1 ] && printf ","
+        printf '%s' "$_map_json_key" > "$_map_json_base.key"
+        printf "%s:" "$(_yq_json_string "$_map_json_base.key")"
+        case "$(yq_node_kind "$_map_json_base.$_map_json_i")" in
+            seq|map)
+                printf "%s" "$(_yq_node_to_json "$_map_json_base.$_map_json_i")"
+                ;;
+            *)
+                if head -n 1 "$_map_json_base.$_map_json_i" | grep -q '^[|>][-+0-9]*[[:space:]]*$'; then
+                    _yq_json_string "$_map_json_base.$_map_json_i"
+                else
+                    printf "%s" "$(_yq_scalar_to_json "$_map_json_base.$_map_json_i")"
+                fi
+                ;;
+        esac
+        rm -f "$_map_json_base.$_map_json_i"
+    done < "$_map_json_keys"
+    printf "}\n"
+    rm -f "$_map_json_keys" "$_map_json_base" "$_map_json_base.key"
+}
 
-            # Remove quotes from value if present
-            if (value ~ /^".*"$/) {
-                value = substr(value, 2, length(value) - 2)
+# Print a scalar (plain, quoted or block scalar) as a JSON string
+_yq_json_string() {
+    awk '` + awkBlockScalarDecode + `
+    {
+        raw[NR] = $0
+    }
+    END {
+        if (NR > 0 && raw[1] ~ /^[|>][-+0-9]*[[:space:]]*$/) {
+            # Block scalar: decode the body with its folding and chomping
+            header = raw[1]
+            sub(/[[:space:]]*$/, "", header)
+            style = substr(header, 1, 1)
+            chomp = ""
+            ind = 0
+            for (i = 2; i <= length(header); i++) {
+                c = substr(header, i, 1)
+                if (c == "-" || c == "+") chomp = c
+                else ind = c + 0
+            }
+            if (ind == 0) {
+                for (i = 2; i <= NR; i++) {
+                    if (raw[i] !~ /^[[:space:]]*$/) {
+                        match(raw[i], /^ */)
+                        ind = RLENGTH
+                        break
+                    }
+                }
+            }
+            n = 0
+            for (i = 2; i <= NR; i++) {
+                n++
+                if (raw[i] ~ /^[[:space:]]*$/ && length(raw[i]) <= ind) lines[n] = ""
+                else lines[n] = substr(raw[i], ind + 1)
+            }
+            v = yq_block_decode(lines, n, style, chomp)
+        } else {
+            v = ""
+            for (i = 1; i <= NR; i++) v = (i == 1) ? raw[i] : v "\n" raw[i]
+            if (v ~ /^".*"$/) {
+                v = substr(v, 2, length(v) - 2)
+                # Unescape the quotes, they are escaped again below
+                gsub(/\\"/, "\"", v)
+                gsub(/\\\\/, "\\", v)
+            } else if (v ~ /^'"'"'.*'"'"'$/) {
+                v = substr(v, 2, length(v) - 2)
+                gsub(/'"'"''"'"'/, "'"'"'", v)
             }
-
-            # Escape JSON special characters in value
-            gsub(/\\/, "\\\\", value)
-            gsub(/"/, "\\\"", value)
-
-            # Output JSON key:value pair
-            printf "\"%s\":\"%s\"", key, value
         }
+        gsub(/\\/, "\\\\\\\\", v)
+        gsub(/"/, "\\\"", v)
+        gsub(/\t/, "\\t", v)
+        gsub(/\r/, "\\r", v)
+        gsub(/\n/, "\\n", v)
+        printf "\"%s\"", v
+    }
+    ' "$1"
+}
+
+# Convert a plain or quoted YAML scalar to a JSON scalar, empty flow
+# collections become empty JSON collections
+_yq_scalar_to_json() {
+    awk '
+    BEGIN {
+        v = ""
+    }
+    {
+        v = (NR == 1) ? $0 : v "\n" $0
     }
     END {
-        # Close final object if one is open
-        if (obj_started == 1) {
-            printf "}\n"
+        if (NR == 0 || v == "null" || v == "~") {
+            print "null"
+        } else if (v == "true" || v == "false") {
+            print v
+        } else if (v ~ /^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$/) {
+            print v
+        } else if (v ~ /^\[[ \t]*\]$/ || v ~ /^\{[ \t]*\}$/) {
+            # Empty flow collections, as written by [] and {} constructions
+            gsub(/[ \t]/, "", v)
+            print v
+        } else if (v ~ /^".*"$/) {
+            # Double-quoted YAML escapes are valid JSON escapes
+            print v
+        } else {
+            if (v ~ /^'"'"'.*'"'"'$/) {
+                v = substr(v, 2, length(v) - 2)
+                gsub(/'"'"''"'"'/, "'"'"'", v)
+            }
+            gsub(/\\/, "\\\\\\\\", v)
+            gsub(/"/, "\\\"", v)
+            gsub(/\t/, "\\t", v)
+            gsub(/\n/, "\\n", v)
+            print "\"" v "\""
         }
     }
-    '
+    ' "$1"
 }
 `
 }
@@ -194,3 +194,38 @@ func TestGenerateJSONProperlyQuotesValues(t *testing.T) {
 		t.Error("JSON converter missing value quoting")
 	}
 }
+
+// TestYqNodeToJSON verifies scalars and sequences are converted per node
+func TestYqNodeToJSON(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateCoreFunctions(),
+		GenerateJSON(),
+	)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "string scalar", input: "John", expected: `"John"`},
+		{name: "number scalar", input: "30", expected: "30"},
+		{name: "null scalar", input: "null", expected: "null"},
+		{name: "single-quoted scalar", input: "'it''s'", expected: `"it's"`},
+		{name: "sequence of scalars", input: "- a\n- 1\n- true", expected: `["a",1,true]`},
+		{name: "nested sequence", input: "- - a\n  - b\n- c", expected: `[["a","b"],"c"]`},
+		{name: "sequence of maps", input: "- name: x\n- name: y", expected: `[{"name":"x"},{"name":"y"}]`},
+		{name: "empty sequence", input: "[]", expected: "[]"},
+		{name: "empty map", input: "{}", expected: "{}"},
+		{name: "quoted brackets", input: `"[]"`, expected: `"[]"`},
+		{name: "nested empty collections", input: "- []\n- a: {}", expected: `[[],{"a":{}}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", tt.input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_node_to_json", testFile)
+		})
+	}
+}
@@ -18,101 +18,1128 @@ package generator
 // GenerateOperators returns assignment and mutation operators
 func GenerateOperators() string {
 	return `
-# Assignment operator - set a value
-yq_assign() {
+# Assignment operator - .path = value
+# The value is evaluated against the whole document, so .a = .b copies a
+# node; every path the left side matches is set to it
+yq_assign() (
     _expr="$1"
     _file="$2"
 
-    # Parse the assignment: .path = value
-    _path=$(echo "$_expr" | sed 's/ =.*//')
-    _value=$(echo "$_expr" | sed 's/.* = //')
+    _yq_split_top "$_expr" " = "
+    _lhs="$_yq_top_left"
+    _rhs="$_yq_top_right"
 
-    # Remove leading dot from path
-    _path=$(echo "$_path" | sed 's/^\.//')
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_assign_value "$_rhs" "$_file" > "$_base.value" || { rm -f "$_base"*; return 1; }
+
+    _yq_target_paths "$_lhs" "$_file" > "$_base.paths"
+    cp "$_file" "$_base.doc"
+    while IFS= read -r _target || [ -n "$_target" ]; do
+        yq_set_path "$_target" "$_base.value" "$_base.doc" > "$_base.next"
+        mv "$_base.next" "$_base.doc"
+    done < "$_base.paths"
+    cat "$_base.doc"
+    rm -f "$_base"*
+)
+
+# Update operator - .path |= expression
+# The expression is evaluated against the current value of each path
+yq_update() (
+    _expr="$1"
+    _file="$2"
+
+    _yq_split_top "$_expr" " |= "
+    _lhs="$_yq_top_left"
+    _rhs="$_yq_top_right"
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_target_paths "$_lhs" "$_file" > "$_base.paths"
+    cp "$_file" "$_base.doc"
+    while IFS= read -r _target || [ -n "$_target" ]; do
+        _yq_node_at "$_target" "$_base.doc" > "$_base.current"
+        _yq_assign_value "$_rhs" "$_base.current" > "$_base.value" || { rm -f "$_base"*; return 1; }
+        yq_set_path "$_target" "$_base.value" "$_base.doc" > "$_base.next"
+        mv "$_base.next" "$_base.doc"
+    done < "$_base.paths"
+    cat "$_base.doc"
+    rm -f "$_base"*
+)
+
+# Print the concrete paths the left side of an assignment matches: .. is
+# every node, plain paths are expanded and anything else is used as is
+_yq_target_paths() {
+    if [ "$1" = ".." ]; then
+        echo "."
+        _yq_all_paths "$2" ""
+    else
+        yq_expand_path "$1" "$2" || printf '%s\n' "$1"
+    fi
+}
+
+# Evaluate the value of an assignment and print it as the node to write.
+# Only the first result is used. Plain strings are written again so that
+# they are quoted when their plain form would read as another type or holds
+# YAML indicators; quoted and block scalars keep their style. A bare word
+# that evaluates to nothing or null is taken as a string (.name = Alice)
+_yq_assign_value() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$1" "$2" > "$_base.out" || { rm -f "$_base"*; return 1; }
+    _out=$(tr -d '[:space:]' < "$_base.out")
+    if { [ -z "$_out" ] || { [ "$_out" = "null" ] && [ "$1" != "null" ]; }; } && printf '%s\n' "$1" | grep -q '^[A-Za-z_][A-Za-z0-9_./-]*$'; then
+        printf '%s' "$1" > "$_base.text"
+        yq_string_scalar "$_base.text"
+        rm -f "$_base"*
+        return
+    fi
+    _count=$(yq_split_results "$_base.out" "$_base.result")
+    if [ "$_count" -eq 0 ]; then
+        echo "null"
+    elif [ "$(yq_node_kind "$_base.result.1")" = "scalar" ] && [ "$(yq_tag "$_base.result.1")" = "!!str" ] && [ "$(yq_style "$_base.result.1")" = '""' ]; then
+        yq_scalar_text "$_base.result.1" > "$_base.text"
+        yq_string_scalar "$_base.text"
+    else
+        awk 'NF || started { started = 1; print }' "$_base.result.1"
+    fi
+    rm -f "$_base"*
+)
+
+# Set the node at a path (.a.b[0].c) to the content of a file, creating
+# missing keys and items. Only the entries along the path are rewritten,
+# every other line of a mapping is copied unchanged
+yq_set_path() (
+    _path="${1#.}"
+    _value="$2"
+    _file="$3"
+
+    if [ -z "$_path" ]; then
+        cat "$_value"
+        return
+    fi
+    case "$_path" in
+        \[*)
+            _seg="${_path%%]*}]"
+            _rest="${_path#*]}"
+            ;;
+        *)
+            _seg=$(printf '%s\n' "$_path" | sed 's/[.[].*//')
+            _rest="${_path#"$_seg"}"
+            ;;
+    esac
+    _rest="${_rest#.}"
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    # Sequence index: rebuild the items with the one at the index replaced
+    case "$_seg" in
+        \[*\])
+            _idx="${_seg#[}"
+            _idx="${_idx%]}"
+            _count=0
+            [ "$(yq_node_kind "$_file")" = "seq" ] && _count=$(yq_split_items "$_file" "$_base.item")
+            [ "$_idx" -lt 0 ] && _idx=$((_count + _idx))
+            _idx=$((_idx + 1))
+            [ -f "$_base.item.$_idx" ] && cp "$_base.item.$_idx" "$_base.child" || echo "null" > "$_base.child"
+            yq_set_path "$_rest" "$_value" "$_base.child" > "$_base.item.$_idx"
+            [ "$_idx" -gt "$_count" ] && _count=$_idx
+            _i=1
+            while [ "$_i" -le "$_count" ]; do
+                [ -s "$_base.item.$_i" ] || echo "null" > "$_base.item.$_i"
+                _yq_seq_item "$_base.item.$_i"
+                _i=$((_i + 1))
+            done
+            rm -f "$_base"*
+            return
+            ;;
+    esac
+
+    _key="$_seg"
+    yq_key_access "$_key" "$_file" > "$_base.child"
+    yq_set_path "$_rest" "$_value" "$_base.child" > "$_base.new"
+    _yq_object_entry "$_key" "$_base.new" > "$_base.entry"
 
-    # Remove quotes from value if it'\''s a string literal
-    _value=$(echo "$_value" | sed 's/^"\(.*\)"$/\1/')
+    case "$(tr -d '[:space:]' < "$_file")" in
+        ""|null|"~"|"{}")
+            cat "$_base.entry"
+            rm -f "$_base"*
+            return
+            ;;
+    esac
 
-    # Update the file with the new value
-    awk -v path="$_path" -v value="$_value" '
+    # Replace the entry at the mapping'''s own indentation, or append it
+    awk -v key="$_key" -v entry="$_base.entry" '` + awkMapKey + `
+    function emit_entry(    line) {
+        while ((getline line < entry) > 0) print (line == "" ? "" : pad line)
+        close(entry)
+        done = 1
+    }
     BEGIN {
-        found = 0
+        map_indent = -1
+        skip = 0
+        blanks = 0
+        done = 0
+    }
+    /^[[:space:]]*$/ {
+        if (skip) blanks++
+        else print
+        next
     }
     {
-        # Check if this line matches the key
-        if ($0 ~ "^" path ":") {
-            # Replace the value
-            print path ": " value
-            found = 1
-        } else {
-            print
+        match($0, /^ */)
+        ind = RLENGTH
+        if (map_indent < 0 && $0 !~ /^[[:space:]]*#/) {
+            map_indent = ind
+            pad = substr($0, 1, ind)
+        }
+        if (skip) {
+            if (ind > map_indent) {
+                blanks = 0
+                next
+            }
+            skip = 0
+            for (; blanks > 0; blanks--) print ""
         }
+        if (!done && ind == map_indent && yq_line_key($0) == key) {
+            emit_entry()
+            skip = 1
+            next
+        }
+        print
     }
     END {
-        # If key was not found, add it
-        if (!found) {
-            print path ": " value
-        }
+        if (!done) emit_entry()
     }
     ' "$_file"
-}
 
-# Update operator - update a value based on expression
-yq_update() {
-    _expr="$1"
+    rm -f "$_base"*
+)
+
+# Print the concrete paths a simple path expression matches, one per line
+# .items[].n on a two item sequence gives .items[0].n and .items[1].n
+# Returns 1 for expressions that are not plain paths
+yq_expand_path() (
+    _path="$1"
     _file="$2"
+    _prefix="$3"
 
-    # Parse the update: .path |= expression
-    _path=$(echo "$_expr" | sed 's/ |=.*//')
-    _update_expr=$(echo "$_expr" | sed 's/.* |= //')
+    printf '%s\n' "$_path" | grep -q '^[.]\{0,1\}\([a-zA-Z0-9_-]*\|\[-\{0,1\}[0-9]*\]\|\.\)*$' || return 1
+    _path="${_path#.}"
+    if [ -z "$_path" ]; then
+        printf '%s\n' "${_prefix:-.}"
+        return
+    fi
+    case "$_path" in
+        \[*)
+            _seg="${_path%%]*}]"
+            _rest="${_path#*]}"
+            ;;
+        *)
+            _seg=$(printf '%s\n' "$_path" | sed 's/[.[].*//')
+            _rest="${_path#"$_seg"}"
+            ;;
+    esac
 
-    # Remove leading dot from path
-    _path_key=$(echo "$_path" | sed 's/^\.//')
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    case "$_seg" in
+        "[]")
+            # Every item of a sequence or every entry of a mapping
+            _kind=$(yq_node_kind "$_file")
+            _count=$(yq_split_items "$_file" "$_base.item")
+            [ "$_kind" = "map" ] && yq_map_keys "$_file" > "$_base.keys"
+            _i=1
+            while [ "$_i" -le "$_count" ]; do
+                if [ "$_kind" = "map" ]; then
+                    _key=$(sed -n "${_i}p" "$_base.keys")
+                    yq_expand_path "$_rest" "$_base.item.$_i" "$_prefix.$_key"
+                else
+                    yq_expand_path "$_rest" "$_base.item.$_i" "$_prefix[$((_i - 1))]"
+                fi
+                _i=$((_i + 1))
+            done
+            ;;
+        \[*\])
+            _idx="${_seg#[}"
+            _idx="${_idx%]}"
+            _count=$(yq_split_items "$_file" "$_base.item")
+            [ "$_idx" -lt 0 ] && _idx=$((_count + _idx))
+            [ -f "$_base.item.$((_idx + 1))" ] || echo "null" > "$_base.item.$((_idx + 1))"
+            yq_expand_path "$_rest" "$_base.item.$((_idx + 1))" "$_prefix[$_idx]"
+            ;;
+        *)
+            yq_key_access "$_seg" "$_file" > "$_base.child"
+            yq_expand_path "$_rest" "$_base.child" "$_prefix.$_seg"
+            ;;
+    esac
+    rm -f "$_base"*
+)
 
-    # Get current value
-    _tmp_current=$(mktemp -p "$_YQ_TEMP_DIR")
-    yq_parse "$_path" "$_file" > "$_tmp_current"
+# Remove the node at a path (.a.b[0].c) when it exists. Like yq_set_path,
+# only the entries along the path are rewritten
+yq_delete_path() (
+    _path="${1#.}"
+    _file="$2"
 
-    # Apply the update expression to the current value
-    _tmp_updated=$(mktemp -p "$_YQ_TEMP_DIR")
-    yq_parse "$_update_expr" "$_tmp_current" > "$_tmp_updated"
-    _new_value=$(cat "$_tmp_updated")
+    if [ -z "$_path" ]; then
+        echo "null"
+        return
+    fi
+    case "$_path" in
+        \[*)
+            _seg="${_path%%]*}]"
+            _rest="${_path#*]}"
+            ;;
+        *)
+            _seg=$(printf '%s\n' "$_path" | sed 's/[.[].*//')
+            _rest="${_path#"$_seg"}"
+            ;;
+    esac
+    _rest="${_rest#.}"
 
-    # Update the file with the new value using AWK - use print consistently
-    awk -v key="$_path_key" -v value="$_new_value" '
-    BEGIN {
-        found = 0
-        first = 1
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _kind=$(yq_node_kind "$_file")
+    case "$_seg" in
+        \[*\])
+            if [ "$_kind" != "seq" ]; then
+                cat "$_file"
+                rm -f "$_base"*
+                return
+            fi
+            _idx="${_seg#[}"
+            _idx="${_idx%]}"
+            _count=$(yq_split_items "$_file" "$_base.item")
+            [ "$_idx" -lt 0 ] && _idx=$((_count + _idx))
+            _idx=$((_idx + 1))
+            if [ -n "$_rest" ] && [ -f "$_base.item.$_idx" ]; then
+                yq_delete_path "$_rest" "$_base.item.$_idx" > "$_base.child"
+                mv "$_base.child" "$_base.item.$_idx"
+            fi
+            : > "$_base.out"
+            _i=1
+            while [ "$_i" -le "$_count" ]; do
+                if [ "$_i" -ne "$_idx" ] || [ -n "$_rest" ]; then
+                    _yq_seq_item "$_base.item.$_i" >> "$_base.out"
+                fi
+                _i=$((_i + 1))
+            done
+            if [ -s "$_base.out" ]; then
+                cat "$_base.out"
+            else
+                echo "[]"
+            fi
+            ;;
+        *)
+            if [ "$_kind" != "map" ] || ! yq_map_keys "$_file" | grep -qFx -- "$_seg"; then
+                cat "$_file"
+            elif [ -z "$_rest" ]; then
+                yq_del ".$_seg" "$_file" > "$_base.out"
+                if [ -s "$_base.out" ]; then
+                    awk '{ print }' "$_base.out"
+                else
+                    echo "{}"
+                fi
+            else
+                yq_key_access "$_seg" "$_file" > "$_base.child"
+                yq_delete_path "$_rest" "$_base.child" > "$_base.new"
+                yq_set_path "$_seg" "$_base.new" "$_file"
+            fi
+            ;;
+    esac
+    rm -f "$_base"*
+)
+
+# Print the segments of a path (.a.b[0]) one per line: k<key> or i<index>
+_yq_path_segments() {
+    printf '%s\n' "$1" | awk '
+    {
+        p = $0
+        while (p != "" && p != ".") {
+            sub(/^\./, "", p)
+            if (match(p, /^\[-?[0-9]+\]/)) {
+                print "i" substr(p, 2, RLENGTH - 2)
+            } else {
+                match(p, /^[^.[]*/)
+                print "k" substr(p, 1, RLENGTH)
+            }
+            p = substr(p, RLENGTH + 1)
+        }
     }
+    '
+}
+
+# Print a path (.a.b[0]) as a sequence of keys and integer indices
+# Keys that read as numbers stay quoted so they differ from indices
+yq_path_to_seq() {
+    _p2s_path="${1#.}"
+    if [ -z "$_p2s_path" ]; then
+        echo "[]"
+        return
+    fi
+    _yq_path_segments "$_p2s_path" | while IFS= read -r _p2s_seg; do
+        case "$_p2s_seg" in
+            i*) echo "- ${_p2s_seg#i}" ;;
+            k*) echo "- $(yq_string_literal "\"${_p2s_seg#k}\"")" ;;
+        esac
+    done
+}
+
+# Read a sequence of keys and integer indices back into a path (.a.b[0])
+yq_seq_to_path() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _path=""
+    if [ "$(yq_node_kind "$1")" = "seq" ]; then
+        _count=$(yq_split_items "$1" "$_base.seg")
+        _i=1
+        while [ "$_i" -le "$_count" ]; do
+            _seg=$(sed -e 's/^[[:space:]]*//' -e 's/[[:space:]]*$//' "$_base.seg.$_i")
+            if printf '%s\n' "$_seg" | grep -q '^-\{0,1\}[0-9][0-9]*$'; then
+                _path="$_path[$_seg]"
+            else
+                _path="$_path.$(yq_unquote "$_seg")"
+            fi
+            _i=$((_i + 1))
+        done
+    fi
+    rm -f "$_base"*
+    printf '%s\n' "${_path:-.}"
+)
+
+# Print the path of every node below the root in document order, one per line
+_yq_all_paths() (
+    _file="$1"
+    _prefix="$2"
+
+    _kind=$(yq_node_kind "$_file")
+    [ "$_kind" = "scalar" ] && return
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _count=$(yq_split_items "$_file" "$_base.item")
+    [ "$_kind" = "map" ] && yq_map_keys "$_file" > "$_base.keys"
+    _i=1
+    while [ "$_i" -le "$_count" ]; do
+        if [ "$_kind" = "map" ]; then
+            _child="$_prefix.$(sed -n "${_i}p" "$_base.keys")"
+        else
+            _child="$_prefix[$((_i - 1))]"
+        fi
+        printf '%s\n' "$_child"
+        _yq_all_paths "$_base.item.$_i" "$_child"
+        _i=$((_i + 1))
+    done
+    rm -f "$_base"*
+)
+
+# Print the node at a path (.a.b[0]) of a document, null when it is missing
+# Segments are looked up one at a time, so keys need no path syntax escaping
+_yq_node_at() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    cp "$2" "$_base.node"
+    _yq_path_segments "$1" > "$_base.segs"
+    while IFS= read -r _seg || [ -n "$_seg" ]; do
+        : > "$_base.next"
+        case "$_seg:$(yq_node_kind "$_base.node")" in
+            i*:seq) yq_array_access "[${_seg#i}]" "$_base.node" > "$_base.next" ;;
+            k*:map) yq_map_keys "$_base.node" | grep -qFx -- "${_seg#k}" && yq_key_access "${_seg#k}" "$_base.node" > "$_base.next" ;;
+        esac
+        [ -s "$_base.next" ] || echo "null" > "$_base.next"
+        mv "$_base.next" "$_base.node"
+    done < "$_base.segs"
+    cat "$_base.node"
+    rm -f "$_base"*
+)
+
+# Print "line column" (1-based) of the node at a path in a document, or
+# "0 0" when the path does not exist. A scalar entry value is positioned
+# where the value starts, a collection at its first entry or item
+yq_node_position() {
+    _yq_path_segments "$1" | awk -v doc="$2" '` + awkMapKey + `
     {
-        # Check if this line matches the key
-        if ($0 ~ "^" key ":") {
-            # Replace the value
-            print key ": " value
-            found = 1
-        } else {
-            if (!first) print ""
-            printf "%s", $0
-            first = 0
+        segs[++nsegs] = $0
+    }
+    function content(i) {
+        return lines[i] !~ /^[[:space:]]*(#.*)?$/ && lines[i] !~ /^(---|\.\.\.)/
+    }
+    function indent_of(i) {
+        match(lines[i], /^ */)
+        return RLENGTH
+    }
+    # Set lo to the first content line of lo..hi, 0 when there is none
+    function first_content(    i) {
+        for (i = lo; i <= hi; i++) {
+            if (content(i)) {
+                lo = i
+                return
+            }
+        }
+        lo = 0
+    }
+    # The block below line i, deeper than ind (a sequence may share ind)
+    function block_end(i, ind,    j) {
+        for (j = i + 1; j <= n; j++) {
+            if (!content(j)) continue
+            if (indent_of(j) < ind) break
+            if (indent_of(j) == ind && lines[j] !~ /^ *-( |$)/) break
         }
+        return j - 1
     }
     END {
-        if (!first) print ""
-        # If key was not found, add it
-        if (!found) {
-            print key ": " value
+        while ((getline line < doc) > 0) lines[++n] = line
+        lo = 1
+        hi = n
+        first_content()
+        inline_col = 0
+        for (s = 1; s <= nsegs && lo > 0; s++) {
+            if (inline_col) {
+                lo = 0
+                break
+            }
+            ind = indent_of(lo)
+            seg = substr(segs[s], 2)
+            found = 0
+            if (segs[s] ~ /^k/) {
+                for (i = lo; i <= hi; i++) {
+                    if (content(i) && indent_of(i) == ind && yq_line_key(lines[i]) == seg) {
+                        found = i
+                        break
+                    }
+                }
+                if (!found) {
+                    lo = 0
+                    break
+                }
+                yq_line_key(lines[found])
+                if (yq_line_value != "" && yq_line_value !~ /^#/) {
+                    lo = found
+                    inline_col = length(lines[found]) - length(yq_line_value) + 1
+                } else {
+                    hi = block_end(found, ind)
+                    lo = found + 1
+                    first_content()
+                }
+            } else {
+                count = -1
+                for (i = lo; i <= hi; i++) {
+                    if (content(i) && indent_of(i) == ind && lines[i] ~ /^ *-( |$)/ && ++count == seg + 0) {
+                        found = i
+                        break
+                    }
+                }
+                if (!found) {
+                    lo = 0
+                    break
+                }
+                hi = block_end(found, ind + 1)
+                if (lines[found] ~ /^ *-[ ]*(#.*)?$/) {
+                    lo = found + 1
+                    first_content()
+                } else {
+                    # The item starts on the dash line: read it as if the
+                    # dash were a space so a compact map keeps its indent
+                    lines[found] = substr(lines[found], 1, ind) " " substr(lines[found], ind + 2)
+                    lo = found
+                    if (yq_line_key(lines[found]) == "" && !yq_line_is_key) inline_col = indent_of(found) + 1
+                }
+            }
         }
+        if (lo == 0) print "0 0"
+        else print lo, (inline_col ? inline_col : indent_of(lo) + 1)
     }
-    ' "$_file"
+    '
+}
 
-    rm -f "$_tmp_current" "$_tmp_updated"
+# Node context: _yq_ctx holds the path of the node being evaluated within
+# the document in _yq_ctx_root, or "" once the node is no longer part of the
+# document (constructed or computed values). Pipes update it for their right
+# side, so key, parent, line and column know where a value came from
+
+# Append a relative path to a context path
+_yq_ctx_join() {
+    if [ -z "$1" ]; then
+        return
+    fi
+    case "$2" in
+        "."|"") printf '%s\n' "$1" ;;
+        *) printf '%s\n' "${1#.}$2" ;;
+    esac
 }
 
+# Print the context of the results of expr evaluated on the node in the
+# current context: plain paths descend, parent goes up, select and the
+# identity keep the node; anything else leaves the document
+_yq_ctx_for() (
+    _expr="$1"
+    _file="$2"
+
+    [ -z "$_yq_ctx" ] && return
+    case "$_expr" in
+        "."|select\(*\))
+            printf '%s\n' "$_yq_ctx"
+            return
+            ;;
+        parent|parent\([0-9]*\))
+            _levels=1
+            [ "$_expr" != "parent" ] && _levels=$(printf '%s' "$_expr" | tr -cd '0-9')
+            _yq_ctx_parent "$_yq_ctx" "$_levels"
+            return
+            ;;
+    esac
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    if yq_expand_path "${_expr%\?}" "$_file" > "$_base" 2>/dev/null && [ "$(wc -l < "$_base")" -eq 1 ]; then
+        _yq_ctx_join "$_yq_ctx" "$(cat "$_base")"
+    fi
+    rm -f "$_base"
+)
+
+# Print the path n levels above a path, nothing above the root
+_yq_ctx_parent() {
+    _yq_path_segments "$1" | awk -v up="$2" '
+    {
+        segs[NR] = $0
+    }
+    END {
+        if (up > NR) exit
+        p = ""
+        for (i = 1; i <= NR - up; i++) {
+            if (segs[i] ~ /^i/) p = p "[" substr(segs[i], 2) "]"
+            else p = p "." substr(segs[i], 2)
+        }
+        print (p == "" ? "." : p)
+    }
+    '
+}
+
+# Key function - the key (or index) of the current node in its parent
+yq_key() {
+    _key_seg=$(_yq_path_segments "$_yq_ctx" | tail -n 1)
+    case "$_key_seg" in
+        i*) printf '%s\n' "${_key_seg#i}" ;;
+        k*) yq_string_literal "\"${_key_seg#k}\"" ;;
+        *) echo "null" ;;
+    esac
+}
+
+# Parent function - parent(n) is the node n levels above the current one
+# There is no parent above the root, so that gives no result
+yq_parent() {
+    [ -z "$_yq_ctx" ] && echo "null" && return
+    _parent_path=$(_yq_ctx_parent "$_yq_ctx" "$1")
+    [ -z "$_parent_path" ] && return
+    _yq_node_at "$_parent_path" "$_yq_ctx_root"
+}
+
+# Line and column functions - where the current node starts in its file,
+# 0 for values that do not come from the document. Documents split from a
+# file by eval-all count their lines from the start of that file
+yq_line() {
+    if [ -z "$_yq_ctx" ]; then
+        echo "0"
+        return
+    fi
+    _line_pos=$(yq_node_position "$_yq_ctx" "$_yq_ctx_root" | cut -d' ' -f"$1")
+    if [ "$1" -eq 1 ] && [ "$_line_pos" -gt 0 ]; then
+        _line_pos=$((_line_pos + ${_yq_line_offset:-0}))
+    fi
+    echo "$_line_pos"
+}
+
+# Split files into their "---" separated documents (base.1, base.2, ...)
+# and write the node list of yq_eval_stream to base: one line per document
+# with the index of its file, the number of file lines before it, the
+# document as its context root and the file name
+yq_split_documents() {
+    _docs_base="$1"
+    shift
+    awk -v base="$_docs_base" '
+    FNR == 1 {
+        file++
+        open = 0
+    }
+    /^---[[:space:]]*$/ {
+        open = 0
+        next
+    }
+    !open && /^[[:space:]]*$/ {
+        next
+    }
+    {
+        if (!open) {
+            if (n > 0) close(out)
+            out = base "." (++n)
+            print (file - 1) "\t" (FNR - 1) "\t" out "\t.\t" FILENAME > base
+            open = 1
+        }
+        print > out
+    }
+    ' "$@"
+    [ -f "$_docs_base" ] || : > "$_docs_base"
+}
+
+# Add a node to a node list: the list, the node file, then its file index,
+# line offset, context root, context path ("-" outside the document) and
+# file name
+_yq_stream_push() {
+    [ -f "$1" ] || : > "$1"
+    _push_n=$(($(wc -l < "$1") + 1))
+    cp "$2" "$1.$_push_n"
+    # A node outside the document is its own root
+    _push_root="$5"
+    [ "$6" = "-" ] && _push_root="$1.$_push_n"
+    printf '%s\t%s\t%s\t%s\t%s\n' "$3" "$4" "$_push_root" "$6" "$7" >> "$1"
+}
+
+# Evaluate a query over a node list the way eval-all does: the query runs
+# once with every document as its input. Pipes, commas, bindings and array
+# construction see the whole list, + - * / % combine every result of their
+# left side with every result of their right side, literals are evaluated
+# once and any other expression runs on each node with the file index, file
+# name, line offset and context of that node. A list (base) holds one line
+# per node, as written by _yq_stream_push, and the nodes in base.1, base.2...
+yq_eval_stream() (
+    _query=$(printf '%s' "$1" | sed '1s/^[[:space:]]*//; $s/[[:space:]]*$//')
+    _list="$2"
+    _out="$3"
+    : > "$_out"
+    _tab=$(printf '\t')
+
+    # Binding: SRC as $name ireduce (INIT; UPDATE) folds the results of SRC
+    # on the whole list, SRC as $name | BODY runs BODY on each node once per
+    # result of SRC on that node
+    _as_pos=$(_yq_find_top_op "$_query" " as \$")
+    _pipe_pos=$(_yq_find_top_op "$_query" " | ")
+    if [ "$_as_pos" -gt 0 ] && { [ "$_pipe_pos" -eq 0 ] || [ "$_as_pos" -lt "$_pipe_pos" ]; }; then
+        _yq_split_top "$_query" " as \$"
+        _src="$_yq_top_left"
+        _var=$(printf '%s' "$_yq_top_right" | sed 's/^\([a-zA-Z0-9_]*\).*/\1/')
+        _rest=$(printf '%s' "$_yq_top_right" | sed 's/^[a-zA-Z0-9_]*[[:space:]]*//')
+        case "$_rest" in
+            ireduce*)
+                yq_eval_stream "$_src" "$_list" "$_out.src" || return 1
+                _count=$(wc -l < "$_out.src")
+                _args="${_rest#ireduce}"
+                _then="."
+                if _yq_split_top "$_args" " | "; then
+                    _args="$_yq_top_left"
+                    _then="$_yq_top_right"
+                fi
+                _args=$(printf '%s' "$_args" | sed 's/^[[:space:]]*(//; s/)[[:space:]]*$//')
+                _yq_split_top "$_args" ";"
+                _update="$_yq_top_right"
+                yq_eval_stream "$_yq_top_left" "$_list" "$_out.init" || return 1
+                if [ -s "$_out.init" ]; then
+                    cp "$_out.init.1" "$_out.acc"
+                else
+                    echo "null" > "$_out.acc"
+                fi
+                _i=1
+                while [ "$_i" -le "$_count" ]; do
+                    eval "_yq_var_${_var}=\"\$_out.src.\$_i\""
+                    _yq_ctx_root=""
+                    _yq_ctx=""
+                    _yq_parse_result "$_update" "$_out.acc" > "$_out.next"
+                    _yq_raised && return 1
+                    mv "$_out.next" "$_out.acc"
+                    _i=$((_i + 1))
+                done
+                # The folded value belongs to the first node of the list
+                IFS="$_tab" read -r _index _offset _root _ctx _name < "$_list" || _index=""
+                _yq_stream_push "$_out.reduced" "$_out.acc" "${_index:-0}" "${_offset:-0}" "" "-" "${_name:-$_yq_filename}"
+                yq_eval_stream "$_then" "$_out.reduced" "$_out"
+                return
+                ;;
+        esac
+        _body=$(printf '%s' "$_rest" | sed 's/^|[[:space:]]*//')
+        _n=0
+        while IFS= read -r _line; do
+            _n=$((_n + 1))
+            rm -f "$_out.node"*
+            printf '%s\n' "$_line" > "$_out.node"
+            cp "$_list.$_n" "$_out.node.1"
+            yq_eval_stream "$_src" "$_out.node" "$_out.src" || return 1
+            _i=1
+            while [ "$_i" -le "$(wc -l < "$_out.src")" ]; do
+                eval "_yq_var_${_var}=\"\$_out.src.\$_i\""
+                yq_eval_stream "$_body" "$_out.node" "$_out.body" || return 1
+                _yq_stream_append "$_out.body" "$_out"
+                _i=$((_i + 1))
+            done
+        done < "$_list"
+        return
+    fi
+
+    # Pipe: the right side runs on the list of results of the left side
+    if _yq_split_top "$_query" " | "; then
+        _right="$_yq_top_right"
+        yq_eval_stream "$_yq_top_left" "$_list" "$_out.left" || return 1
+        yq_eval_stream "$_right" "$_out.left" "$_out"
+        return
+    fi
+
+    # Comma: the results of each side one after another
+    if _yq_split_top "$_query" ","; then
+        _right="$_yq_top_right"
+        yq_eval_stream "$_yq_top_left" "$_list" "$_out.left" || return 1
+        yq_eval_stream "$_right" "$_list" "$_out.right" || return 1
+        _yq_stream_append "$_out.left" "$_out"
+        _yq_stream_append "$_out.right" "$_out"
+        return
+    fi
+
+    # Parentheses around the whole query
+    if [ "${_query#(}" != "$_query" ] && _yq_is_group "$_query"; then
+        yq_eval_stream "$(printf '%s' "$_query" | sed 's/^(//; s/)[[:space:]]*$//')" "$_list" "$_out"
+        return
+    fi
+
+    # Array construction collects the results of every node
+    if [ "${_query#\[}" != "$_query" ] && _yq_is_group "$_query"; then
+        _inner=$(printf '%s' "$_query" | sed 's/^\[//; s/\][[:space:]]*$//')
+        : > "$_out.items"
+        if [ -n "$(printf '%s' "$_inner" | tr -d '[:space:]')" ]; then
+            yq_eval_stream "$_inner" "$_list" "$_out.items" || return 1
+        fi
+        _count=$(wc -l < "$_out.items")
+        if [ "$_count" -eq 0 ]; then
+            echo "[]" > "$_out.seq"
+        else
+            _i=1
+            while [ "$_i" -le "$_count" ]; do
+                _yq_seq_item "$_out.items.$_i"
+                _i=$((_i + 1))
+            done > "$_out.seq"
+        fi
+        IFS="$_tab" read -r _index _offset _root _ctx _name < "$_list" || _index=""
+        _yq_stream_push "$_out" "$_out.seq" "${_index:-0}" "${_offset:-0}" "" "-" "${_name:-$_yq_filename}"
+        return
+    fi
+
+    # Arithmetic and merges: every pair of a left and a right result.
+    # Comparisons and assignments bind looser and run on each node
+    _loose=0
+    for _loose_op in " == " " != " " = " " |= " " // "; do
+        [ "$(_yq_find_top_op "$_query" "$_loose_op")" -gt 0 ] && _loose=1
+    done
+    if [ "$_loose" -eq 0 ] && _yq_split_arith "$_query"; then
+        _op="$_yq_arith_op$_yq_arith_flags"
+        _right="$_yq_arith_right"
+        yq_eval_stream "$_yq_arith_left" "$_list" "$_out.left" || return 1
+        yq_eval_stream "$_right" "$_list" "$_out.right" || return 1
+        _l=0
+        while IFS="$_tab" read -r _index _offset _root _ctx _name; do
+            _l=$((_l + 1))
+            _r=1
+            while [ "$_r" -le "$(wc -l < "$_out.right")" ]; do
+                _yq_var___lhs="$_out.left.$_l"
+                _yq_var___rhs="$_out.right.$_r"
+                _yq_ctx_root=""
+                _yq_ctx=""
+                _yq_parse_result "\$__lhs $_op \$__rhs" "$_out.left.$_l" > "$_out.pair"
+                _yq_raised && return 1
+                _yq_stream_push "$_out" "$_out.pair" "$_index" "$_offset" "" "-" "$_name"
+                _r=$((_r + 1))
+            done
+        done < "$_out.left"
+        return
+    fi
+
+    # Literals do not depend on their input and give one result
+    if printf '%s\n' "$_query" | grep -q '^\(true\|false\|null\|-\{0,1\}[0-9][0-9.]*\|"[^"\\]*"\|\$[a-zA-Z_][a-zA-Z0-9_]*\)$'; then
+        _index=""
+        IFS="$_tab" read -r _index _offset _root _ctx _name < "$_list" || _index=""
+        _yq_ctx_root=""
+        _yq_ctx=""
+        printf 'null\n' > "$_out.input"
+        _yq_parse_result "$_query" "$_out.input" > "$_out.res" || return 1
+        _yq_stream_push "$_out" "$_out.res" "${_index:-0}" "${_offset:-0}" "" "-" "${_name:-$_yq_filename}"
+        return
+    fi
+
+    # Anything else runs on each node with its file, line offset and context
+    _status=0
+    _i=0
+    while IFS="$_tab" read -r _yq_file_index _yq_line_offset _yq_ctx_root _yq_ctx _yq_filename; do
+        _i=$((_i + 1))
+        [ "$_yq_ctx" = "-" ] && _yq_ctx=""
+        _yq_parse_result "$_query" "$_list.$_i" > "$_out.res" || _status=1
+        _yq_raised && return 1
+        [ -s "$_out.res" ] || continue
+        _count=$(yq_split_results "$_out.res" "$_out.res")
+        # Results keep their place in the document when it is known
+        : > "$_out.paths"
+        if [ "$_count" -eq 1 ]; then
+            _yq_ctx_for "$_query" "$_list.$_i" > "$_out.paths"
+        elif [ -n "$_yq_ctx" ] && yq_expand_path "${_query%\?}" "$_list.$_i" > "$_out.paths" 2>/dev/null &&
+            [ "$(wc -l < "$_out.paths")" -eq "$_count" ]; then
+            while IFS= read -r _path; do
+                _yq_ctx_join "$_yq_ctx" "$_path"
+            done < "$_out.paths" > "$_out.ctx"
+            mv "$_out.ctx" "$_out.paths"
+        fi
+        _j=1
+        while [ "$_j" -le "$_count" ]; do
+            _ctx=$(sed -n "${_j}p" "$_out.paths")
+            _yq_stream_push "$_out" "$_out.res.$_j" "$_yq_file_index" "$_yq_line_offset" "$_yq_ctx_root" "${_ctx:--}" "$_yq_filename"
+            _j=$((_j + 1))
+        done
+    done < "$_list"
+    return $_status
+)
+
+# Append the nodes of a list to another list
+_yq_stream_append() {
+    [ -f "$2" ] || : > "$2"
+    _append_i=0
+    while IFS= read -r _append_line; do
+        _append_i=$((_append_i + 1))
+        _append_n=$(($(wc -l < "$2") + 1))
+        cp "$1.$_append_i" "$2.$_append_n"
+        printf '%s\n' "$_append_line" >> "$2"
+    done < "$1"
+}
+
+# Evaluate a query over the documents of eval-all and print the results
+# separated by blank lines
+yq_eval_all() {
+    _all_out=$(mktemp -p "$_YQ_TEMP_DIR")
+    yq_eval_stream "$1" "$2" "$_all_out"
+    _all_status=$?
+    _all_i=1
+    while [ "$_all_i" -le "$(wc -l < "$_all_out")" ]; do
+        [ "$_all_i" -gt 1 ] && echo ""
+        cat "$_all_out.$_all_i"
+        [ -n "$(tail -c 1 "$_all_out.$_all_i")" ] && echo ""
+        _all_i=$((_all_i + 1))
+    done
+    return $_all_status
+}
+
+# Path function - path(expr) prints the paths a path expression matches as
+# sequences of keys and indices; path(..) includes the root
+yq_path() (
+    _expr="$1"
+    _file="$2"
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    if [ "$_expr" = ".." ]; then
+        echo "." > "$_base.paths"
+        _yq_all_paths "$_file" "" >> "$_base.paths"
+    elif ! yq_expand_path "$_expr" "$_file" > "$_base.paths"; then
+        rm -f "$_base"*
+        _yq_error "path($_expr) needs a path expression"
+        return
+    fi
+    _first=1
+    while IFS= read -r _target || [ -n "$_target" ]; do
+        [ "$_first" -eq 0 ] && echo ""
+        yq_path_to_seq "$_target"
+        _first=0
+    done < "$_base.paths"
+    rm -f "$_base"*
+)
+
+# Paths function - every path below the root, or with a filter only the
+# paths whose value the filter finds truthy: paths(. == "x")
+yq_paths() (
+    _filter="$1"
+    _file="$2"
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_all_paths "$_file" "" > "$_base.paths"
+    _ctx="$_yq_ctx"
+    _first=1
+    while IFS= read -r _target || [ -n "$_target" ]; do
+        if [ -n "$_filter" ]; then
+            _yq_node_at "$_target" "$_file" > "$_base.value"
+            _yq_ctx=$(_yq_ctx_join "$_ctx" "$_target")
+            _yq_parse_result "$_filter" "$_base.value" > "$_base.match"
+            _yq_truthy "$_base.match" || continue
+        fi
+        [ "$_first" -eq 0 ] && echo ""
+        yq_path_to_seq "$_target"
+        _first=0
+    done < "$_base.paths"
+    rm -f "$_base"*
+)
+
+# Getpath function - the value at a path given as a sequence, null if missing
+# Segments are looked up one at a time, so any key works, not only the ones
+# the path syntax can spell
+yq_getpath() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$1" "$2" > "$_base.path"
+    cp "$2" "$_base.value"
+    _count=0
+    [ "$(yq_node_kind "$_base.path")" = "seq" ] && _count=$(yq_split_items "$_base.path" "$_base.seg")
+    _i=1
+    while [ "$_i" -le "$_count" ]; do
+        _seg=$(sed -e 's/^[[:space:]]*//' -e 's/[[:space:]]*$//' "$_base.seg.$_i")
+        case "$(yq_node_kind "$_base.value")" in
+            seq)
+                printf '%s\n' "$_seg" | grep -q '^-\{0,1\}[0-9][0-9]*$' && yq_array_access "[$_seg]" "$_base.value" > "$_base.next"
+                ;;
+            map)
+                yq_key_access "$(yq_unquote "$_seg")" "$_base.value" > "$_base.next"
+                ;;
+        esac
+        [ -s "$_base.next" ] || echo "null" > "$_base.next"
+        mv "$_base.next" "$_base.value"
+        _i=$((_i + 1))
+    done
+    cat "$_base.value"
+    rm -f "$_base"*
+)
+
+# Setpath function - setpath(PATH; VALUE) with both evaluated against the input
+yq_setpath() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$1" "$3" > "$_base.path"
+    _yq_parse_result "$2" "$3" > "$_base.value"
+    yq_set_path "$(yq_seq_to_path "$_base.path")" "$_base.value" "$3"
+    rm -f "$_base"*
+)
+
+# Delpaths function - delpaths(PATHS) removes every path of a sequence of paths
+# Paths are removed from the last one in document order so earlier removals
+# cannot shift the indices of later ones
+yq_delpaths() (
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$1" "$2" > "$_base.paths"
+    _count=0
+    [ "$(yq_node_kind "$_base.paths")" = "seq" ] && _count=$(yq_split_items "$_base.paths" "$_base.path")
+    : > "$_base.list"
+    _i=1
+    while [ "$_i" -le "$_count" ]; do
+        yq_seq_to_path "$_base.path.$_i" >> "$_base.list"
+        _i=$((_i + 1))
+    done
+
+    cp "$2" "$_base.doc"
+    awk '
+    {
+        key = $0
+        out = ""
+        while (match(key, /\[[0-9]+\]/)) {
+            out = out substr(key, 1, RSTART) sprintf("%010d", substr(key, RSTART + 1, RLENGTH - 2)) "]"
+            key = substr(key, RSTART + RLENGTH)
+        }
+        print out key "\t" $0
+    }
+    ' "$_base.list" | sort -r | cut -f2 > "$_base.sorted"
+    while IFS= read -r _target || [ -n "$_target" ]; do
+        yq_delete_path "$_target" "$_base.doc" > "$_base.next"
+        mv "$_base.next" "$_base.doc"
+    done < "$_base.sorted"
+    cat "$_base.doc"
+    rm -f "$_base"*
+)
+
+# Pick function - pick(KEYS) keeps the given keys of a mapping (or indices of
+# a sequence) in the order of the list, skipping the ones that do not exist
+# Omit function - omit(KEYS) is the reverse and drops them
+yq_pick() (
+    _mode="$1"
+    _file="$3"
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$2" "$_file" > "$_base.list"
+    : > "$_base.wanted"
+    if [ "$(yq_node_kind "$_base.list")" = "seq" ]; then
+        _n=$(yq_split_items "$_base.list" "$_base.want")
+        _i=1
+        while [ "$_i" -le "$_n" ]; do
+            yq_unquote "$(sed -e 's/^[[:space:]]*//' -e 's/[[:space:]]*$//' "$_base.want.$_i")" >> "$_base.wanted"
+            _i=$((_i + 1))
+        done
+    fi
+
+    _kind=$(yq_node_kind "$_file")
+    if [ "$_kind" = "scalar" ]; then
+        cat "$_file"
+        rm -f "$_base"*
+        return
+    fi
+    _count=$(yq_split_items "$_file" "$_base.item")
+    if [ "$_kind" = "map" ]; then
+        yq_map_keys "$_file" > "$_base.keys"
+    else
+        _i=0
+        while [ "$_i" -lt "$_count" ]; do
+            echo "$_i"
+            _i=$((_i + 1))
+        done > "$_base.keys"
+    fi
+
+    # Entries to keep, as item numbers in output order
+    if [ "$_mode" = "pick" ]; then
+        while IFS= read -r _want || [ -n "$_want" ]; do
+            [ "$_kind" = "seq" ] && [ "${_want#-}" != "$_want" ] && _want=$((_count + _want))
+            grep -nFx -- "$_want" "$_base.keys" | head -n 1 | cut -d: -f1
+        done < "$_base.wanted" > "$_base.keep"
+    else
+        _i=1
+        while IFS= read -r _key || [ -n "$_key" ]; do
+            grep -qFx -- "$_key" "$_base.wanted" || echo "$_i"
+            _i=$((_i + 1))
+        done < "$_base.keys" > "$_base.keep"
+    fi
+
+    : > "$_base.out"
+    while IFS= read -r _i || [ -n "$_i" ]; do
+        if [ "$_kind" = "map" ]; then
+            _yq_object_entry "$(sed -n "${_i}p" "$_base.keys")" "$_base.item.$_i" >> "$_base.out"
+        else
+            _yq_seq_item "$_base.item.$_i" >> "$_base.out"
+        fi
+    done < "$_base.keep"
+
+    if [ -s "$_base.out" ]; then
+        cat "$_base.out"
+    elif [ "$_kind" = "map" ]; then
+        echo "{}"
+    else
+        echo "[]"
+    fi
+    rm -f "$_base"*
+)
+
+# Compound assignment - LHS op= RHS sets every path LHS matches to its
+# current value "op" RHS, with RHS evaluated once against the whole document
+# op is one of + - * / % //
+yq_compound_assign() (
+    _op="$1"
+    _lhs="$2"
+    _rhs="$3"
+    _file="$4"
+
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+    _yq_parse_result "$_rhs" "$_file" > "$_base.rhs"
+    _yq_var___rhs="$_base.rhs"
+
+    yq_expand_path "$_lhs" "$_file" > "$_base.paths" || printf '%s\n' "$_lhs" > "$_base.paths"
+    cp "$_file" "$_base.doc"
+    while IFS= read -r _target || [ -n "$_target" ]; do
+        _yq_parse_result "$_target" "$_base.doc" > "$_base.current"
+        _yq_parse_result ". $_op \$__rhs" "$_base.current" > "$_base.new" || return 1
+        yq_set_path "$_target" "$_base.new" "$_base.doc" > "$_base.next"
+        mv "$_base.next" "$_base.doc"
+    done < "$_base.paths"
+    cat "$_base.doc"
+
+    rm -f "$_base"*
+)
+
 # Delete function - remove a key from object
 yq_del() {
     _path="$1"
     _file="$2"
 
+    # del(.a, .b): delete each comma-separated path in turn
+    if [ "${_path#*,}" != "$_path" ] && _yq_split_top "$_path" ","; then
+        _del_rest="$_yq_top_right"
+        _del_tmp=$(mktemp -p "$_YQ_TEMP_DIR")
+        yq_del "$_yq_top_left" "$_file" > "$_del_tmp"
+        yq_del "$_del_rest" "$_del_tmp"
+        rm -f "$_del_tmp"
+        return
+    fi
+
     # Remove leading dot
     _path=$(echo "$_path" | sed 's/^\.//')
 
@@ -204,11 +1231,12 @@ yq_del() {
         }
         ' "$_file"
     else
-        # Single key deletion
-        awk -v key="$_path" '
+        # Single key deletion, only at the mapping level of the document
+        awk -v key="$_path" '` + awkMapKey + `
         BEGIN {
             skip = 0
             key_indent = -1
+            map_indent = -1
             first = 1
         }
         {
@@ -222,8 +1250,12 @@ yq_del() {
                 }
             }
 
+            if (map_indent == -1 && $0 !~ /^[[:space:]]*(#.*)?$/ && $0 !~ /^(---|\.\.\.)/) {
+                map_indent = current_indent
+            }
+
             # Check if this is the key to delete
-            if (!skip && $0 ~ "^[[:space:]]*" key ":") {
+            if (!skip && current_indent == map_indent && yq_line_key($0) == key) {
                 skip = 1
                 key_indent = current_indent
                 next
@@ -20,13 +20,21 @@ import (
 )
 
 func TestYqAssign(t *testing.T) {
-	code := GenerateOperators()
-	tester := NewShellFunctionTester(t, code)
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateParser(),
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+		GenerateJSON(),
+		GenerateEncoding(),
+		GenerateDatetime(),
+	)
 	defer tester.Cleanup()
 
 	t.Run("simple assignment", func(t *testing.T) {
 		testFile := tester.WriteFile("test.yaml", "name: John\nage: 30")
-		output, _ := tester.ExecuteFunction("yq_assign", ".name = Alice", testFile)
+		output, _ := tester.ExecuteFunction("_yq_init_temp_dir; yq_assign", ".name = Alice", testFile)
 
 		// Should contain the updated value
 		if output == "" {
@@ -36,7 +44,7 @@ func TestYqAssign(t *testing.T) {
 
 	t.Run("new key assignment", func(t *testing.T) {
 		testFile := tester.WriteFile("test.yaml", "name: John")
-		output, _ := tester.ExecuteFunction("yq_assign", ".age = 25", testFile)
+		output, _ := tester.ExecuteFunction("_yq_init_temp_dir; yq_assign", ".age = 25", testFile)
 
 		// Should contain both original and new key
 		if output == "" {
@@ -45,6 +53,51 @@ func TestYqAssign(t *testing.T) {
 	})
 }
 
+func TestYqAssignTypes(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateParser(),
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+		GenerateJSON(),
+		GenerateEncoding(),
+		GenerateDatetime(),
+	)
+	defer tester.Cleanup()
+
+	input := "version: 2\nmeta:\n  name: api\nitems:\n  - x: 1\n  - x: 2"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "number-like string is quoted", query: `.version = "1.0" | .version`, expected: "\"1.0\""},
+		{name: "number stays plain", query: `.version = 1.0 | .version`, expected: "1.0"},
+		{name: "bool-like string is quoted", query: `.version = "true" | .version`, expected: "\"true\""},
+		{name: "null-like string is quoted", query: `.version = "~" | .version`, expected: "\"~\""},
+		{name: "null stays plain", query: `.version = null | .version`, expected: "null"},
+		{name: "indicator is quoted", query: `.version = "a: b" | .version`, expected: "\"a: b\""},
+		{name: "comment marker is quoted", query: `.version = "a #b" | .version`, expected: "\"a #b\""},
+		{name: "leading indicator is quoted", query: `.version = "- x" | .version`, expected: "\"- x\""},
+		{name: "quotes are escaped", query: `.version = "say \"hi\": now" | .version`, expected: "\"say \\\"hi\\\": now\""},
+		{name: "plain string stays plain", query: `.version = "v2" | .version`, expected: "v2"},
+		{name: "nested path", query: `.meta.name = "False"`, expected: "version: 2\nmeta:\n  name: \"False\"\nitems:\n  - x: 1\n  - x: 2"},
+		{name: "every match", query: `.items[].x = "0x1F"`, expected: "version: 2\nmeta:\n  name: api\nitems:\n  - x: \"0x1F\"\n  - x: \"0x1F\""},
+		{name: "copy a node", query: `.meta.v = .version | .meta`, expected: "name: api\nv: 2"},
+		{name: "update", query: `.items[].x |= . * 10 | .items`, expected: "- x: 10\n- x: 20"},
+		{name: "update string", query: `.meta.name |= "1e3" | .meta`, expected: "name: \"1e3\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+}
+
 func TestYqDelete(t *testing.T) {
 	code := GenerateOperators()
 	tester := NewShellFunctionTester(t, code)
@@ -60,3 +113,242 @@ func TestYqDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestYqAssignMultiline(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateParser(),
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+		GenerateJSON(),
+		GenerateEncoding(),
+		GenerateDatetime(),
+	)
+	defer tester.Cleanup()
+
+	t.Run("multi-line value is written as literal block", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "name: John\nage: 30")
+		tester.ExecuteFunctionExpect("name: |-\n  a\n  b\nage: 30", "_yq_init_temp_dir; yq_assign", `.name = "a\nb"`, testFile)
+	})
+
+	t.Run("trailing newline uses clip chomping", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "name: John")
+		tester.ExecuteFunctionExpect("name: |\n  a\n  b", "_yq_init_temp_dir; yq_assign", `.name = "a\nb\n"`, testFile)
+	})
+
+	t.Run("replacing a block scalar drops its old body", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "script: |\n  echo a\n  echo b\nage: 30")
+		tester.ExecuteFunctionExpect("script: x\nage: 30", "_yq_init_temp_dir; yq_assign", ".script = x", testFile)
+	})
+}
+
+func TestYqDeleteIndentationScope(t *testing.T) {
+	code := GenerateOperators()
+	tester := NewShellFunctionTester(t, code)
+	defer tester.Cleanup()
+
+	t.Run("nested key with same name is kept", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "metadata:\n  name: inner\nname: outer")
+		tester.ExecuteFunctionExpect("metadata:\n  name: inner", "yq_del", ".name", testFile)
+	})
+}
+
+func TestYqSetPath(t *testing.T) {
+	// yq_set_path renders entries with the advanced functions
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+	)
+	defer tester.Cleanup()
+
+	input := "# top\nname: box\nmeta:\n  labels:\n    a: 1\n\n  owner: me\ntail: end"
+
+	tests := []struct {
+		name     string
+		path     string
+		value    string
+		expected string
+	}{
+		{name: "replace nested", path: ".meta.labels", value: "- x", expected: "# top\nname: box\nmeta:\n  labels:\n    - x\n\n  owner: me\ntail: end"},
+		{name: "add nested key", path: ".meta.new", value: "v", expected: "# top\nname: box\nmeta:\n  labels:\n    a: 1\n\n  owner: me\n  new: v\ntail: end"},
+		{name: "create path", path: ".x.y", value: "1", expected: "# top\nname: box\nmeta:\n  labels:\n    a: 1\n\n  owner: me\ntail: end\nx:\n  y: 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			valueFile := tester.WriteFile("value.yaml", tt.value)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_set_path", tt.path, valueFile, testFile)
+		})
+	}
+}
+
+func TestYqExpandPath(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+	)
+	defer tester.Cleanup()
+
+	input := "items:\n  - n: 1\n  - n: 2\nm:\n  a: 1\n  b: 2"
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "key path", path: ".m.a", expected: ".m.a"},
+		{name: "sequence iteration", path: ".items[].n", expected: ".items[0].n\n.items[1].n"},
+		{name: "mapping iteration", path: ".m[]", expected: ".m.a\n.m.b"},
+		{name: "negative index", path: ".items[-1].n", expected: ".items[1].n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_expand_path", tt.path, testFile)
+		})
+	}
+
+	t.Run("not a path", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", input)
+		tester.ExecuteFunctionExpectError("yq_expand_path", ".items | length", testFile)
+	})
+}
+
+func TestYqDeletePath(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+	)
+	defer tester.Cleanup()
+
+	input := "name: box\nmeta:\n  ports:\n    - 80\n    - 443\n  owner: me"
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "top key", path: ".name", expected: "meta:\n  ports:\n    - 80\n    - 443\n  owner: me"},
+		{name: "nested key", path: ".meta.owner", expected: "name: box\nmeta:\n  ports:\n    - 80\n    - 443"},
+		{name: "index", path: ".meta.ports[0]", expected: "name: box\nmeta:\n  ports:\n    - 443\n  owner: me"},
+		{name: "missing", path: ".meta.nope.x", expected: input},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_delete_path", tt.path, testFile)
+		})
+	}
+}
+
+func TestYqPathFunctions(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateParser(),
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+	)
+	defer tester.Cleanup()
+
+	input := "name: api\nspec:\n  replicas: 2\n  ports:\n    - 80\n    - 443\n\"8\": eight"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "path", query: "path(.spec.ports[])", expected: "- spec\n- ports\n- 0\n\n- spec\n- ports\n- 1"},
+		{name: "paths", query: "[paths] | length", expected: "7"},
+		{name: "paths filter", query: "paths(. == 443)", expected: "- spec\n- ports\n- 1"},
+		{name: "numeric key stays a string", query: "[paths] | .[6]", expected: "- \"8\""},
+		{name: "getpath", query: "getpath([\"spec\", \"ports\", -1])", expected: "443"},
+		{name: "getpath quoted key", query: "getpath([\"8\"])", expected: "eight"},
+		{name: "getpath missing", query: "getpath([\"name\", \"x\"])", expected: "null"},
+		{name: "setpath", query: "setpath([\"spec\", \"replicas\"]; 5) | .spec.replicas", expected: "5"},
+		{name: "setpath creates", query: "setpath([\"meta\", \"app\"]; .name) | .meta", expected: "app: api"},
+		{name: "delpaths", query: "delpaths([[\"spec\", \"ports\", 0], [\"spec\", \"ports\", 1], [\"name\"]]) | .spec", expected: "replicas: 2\nports: []"},
+		{name: "pick", query: "pick([\"spec\", \"name\", \"zz\"]) | keys", expected: "- spec\n- name"},
+		{name: "omit", query: "omit([\"spec\"])", expected: "name: api\n\"8\": eight"},
+		{name: "pick indices", query: ".spec.ports | pick([1])", expected: "- 443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+func TestYqNodePosition(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+	)
+	defer tester.Cleanup()
+
+	input := "# header\nname: api\nspec:\n  image: nginx\n  ports:\n    - 80\n    - port: 443\n      proto: tcp\n  tags:\n  - a\n  - latest"
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "root", path: ".", expected: "2 1"},
+		{name: "scalar value", path: ".spec.image", expected: "4 10"},
+		{name: "mapping", path: ".spec", expected: "4 3"},
+		{name: "sequence", path: ".spec.ports", expected: "6 5"},
+		{name: "scalar item", path: ".spec.ports[0]", expected: "6 7"},
+		{name: "compact map item", path: ".spec.ports[1]", expected: "7 7"},
+		{name: "key of compact map", path: ".spec.ports[1].proto", expected: "8 14"},
+		{name: "sequence at key indent", path: ".spec.tags[1]", expected: "11 5"},
+		{name: "missing", path: ".spec.nope", expected: "0 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_node_position", tt.path, testFile)
+		})
+	}
+}
+
+func TestYqEvalAll(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "file index", query: "select(fileIndex == 1) | filename", expected: "b.yaml\n\nb.yaml"},
+		{name: "line in its file", query: ".x | line", expected: "0\n\n3\n\n6"},
+		{name: "per document", query: ".x", expected: "null\n\n9\n\n7"},
+		{name: "collect every document", query: "[.] | length", expected: "3"},
+		{name: "merge across files", query: "select(fileIndex == 0) * select(fileIndex == 1)", expected: "a: 1\nb: 2\nx: 9\n\na: 1\nb: 2\nx: 7"},
+		{name: "reduce in a group", query: "(.  as $d ireduce ({}; . * $d)) | .x", expected: "7"},
+		{name: "bind per document", query: ".x as $v | [$v, fileIndex]", expected: "- null\n- 0\n\n- 9\n- 1\n\n- 7\n- 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tester.WriteFile("a.yaml", "a: 1\nb: 2\n")
+			tester.WriteFile("b.yaml", "# head\n\nx: 9\n---\n\nx: 7\n")
+			docs := tester.WriteFile("docs", "")
+			// Single-quoted so the shell does not expand $name
+			tester.ExecuteFunctionExpect(tt.expected,
+				"_yq_init_temp_dir; cd "+tester.tmpDir+" && yq_split_documents "+docs+" a.yaml b.yaml && yq_eval_all '"+tt.query+"'",
+				docs)
+		})
+	}
+}
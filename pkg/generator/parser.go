@@ -18,6 +18,373 @@ package generator
 // GenerateParser returns the yq_parse recursive parser function
 func GenerateParser() string {
 	return `
+# Print the 1-based offset of the first occurrence of an operator that is not
+# nested in quotes, parentheses, brackets or braces (0 when there is none)
+# With "last" as third argument the last occurrence is printed instead, which
+# left-associative operators such as - split on
+_yq_find_top_op() {
+    printf '%s' "$1" | awk -v op="$2" -v last="$3" '
+    {
+        q = (NR == 1) ? $0 : q "\n" $0
+    }
+    END {
+        depth = 0
+        in_str = 0
+        oplen = length(op)
+        for (i = 1; i <= length(q); i++) {
+            c = substr(q, i, 1)
+            if (in_str) {
+                if (c == "\\" && substr(q, i + 1, 1) == "(") {
+                    # String interpolation: the expression nests like parentheses
+                    i++
+                    in_str = 0
+                    interp[++nint] = ++depth
+                } else if (c == "\\") i++
+                else if (c == "\"") in_str = 0
+                continue
+            }
+            if (c == ")" && nint > 0 && depth == interp[nint]) {
+                depth--
+                nint--
+                in_str = 1
+                continue
+            }
+            if (c == "\"") {
+                in_str = 1
+            } else if (c == "(" || c == "[" || c == "{") {
+                depth++
+            } else if (c == ")" || c == "]" || c == "}") {
+                depth--
+            } else if (c ~ /[a-z]/ && (i == 1 || substr(q, i - 1, 1) ~ /[ (|;,]/)) {
+                # if ... end nests like brackets
+                match(substr(q, i), /^[a-z_]+/)
+                w = substr(q, i, RLENGTH)
+                if (w == "if") {
+                    depth++
+                    kw++
+                } else if (w == "end" && kw > 0) {
+                    depth--
+                    kw--
+                }
+                i += RLENGTH - 1
+            } else if (depth == 0 && substr(q, i, oplen) == op) {
+                found = i
+                if (last != "last") break
+                i += oplen - 1
+            }
+        }
+        print found + 0
+    }
+    '
+}
+
+# Succeed when the bracket opening the query, ( [ or {, is closed by its
+# last character, i.e. the whole query is one group such as {a: .b}
+_yq_is_group() {
+    printf '%s' "$1" | awk '
+    {
+        q = (NR == 1) ? $0 : q "\n" $0
+    }
+    END {
+        sub(/[[:space:]]+$/, "", q)
+        if (q !~ /^[([{]/) exit 1
+        depth = 0
+        in_str = 0
+        for (i = 1; i <= length(q); i++) {
+            c = substr(q, i, 1)
+            if (in_str) {
+                if (c == "\\" && substr(q, i + 1, 1) == "(") {
+                    # String interpolation: the expression nests like parentheses
+                    i++
+                    in_str = 0
+                    interp[++nint] = ++depth
+                } else if (c == "\\") i++
+                else if (c == "\"") in_str = 0
+                continue
+            }
+            if (c == ")" && nint > 0 && depth == interp[nint]) {
+                depth--
+                nint--
+                in_str = 1
+                continue
+            }
+            if (c == "\"") {
+                in_str = 1
+            } else if (c == "(" || c == "[" || c == "{") {
+                depth++
+            } else if (c == ")" || c == "]" || c == "}") {
+                depth--
+                if (depth == 0) exit (i == length(q) ? 0 : 1)
+            }
+        }
+        exit 1
+    }
+    '
+}
+
+# Split a query on the first (or with "last", the last) top-level occurrence
+# of an operator
+# Sets _yq_top_left and _yq_top_right (trimmed), returns 1 when not found
+_yq_split_top() {
+    _yq_top_pos=$(_yq_find_top_op "$1" "$2" "$3")
+    if [ "$_yq_top_pos" -eq 0 ]; then
+        return 1
+    fi
+    _yq_top_left=$(printf '%s' "$1" | awk -v n="$_yq_top_pos" '
+    { q = (NR == 1) ? $0 : q "\n" $0 }
+    END { q = substr(q, 1, n - 1); gsub(/^[[:space:]]+|[[:space:]]+$/, "", q); printf "%s", q }
+    ')
+    _yq_top_right=$(printf '%s' "$1" | awk -v n="$_yq_top_pos" -v oplen="${#2}" '
+    { q = (NR == 1) ? $0 : q "\n" $0 }
+    END { q = substr(q, n + oplen); gsub(/^[[:space:]]+|[[:space:]]+$/, "", q); printf "%s", q }
+    ')
+    return 0
+}
+
+# Split a whole "if C then T elif C then T else E end" expression into one
+# line per part, prefixed C: (condition), T: (then branch) or E: (else branch)
+# Returns 1 when the query is not a single if ... end expression
+_yq_if_parts() {
+    printf '%s' "$1" | awk '
+    function trim(s) {
+        gsub(/^[[:space:]]+|[[:space:]]+$/, "", s)
+        return s
+    }
+    {
+        q = (NR == 1) ? $0 : q " " $0
+    }
+    END {
+        q = trim(q)
+        if (q !~ /^if[ (]/) exit 1
+        depth = 0
+        in_str = 0
+        n = 0
+        for (i = 1; i <= length(q); i++) {
+            c = substr(q, i, 1)
+            if (in_str) {
+                if (c == "\\" && substr(q, i + 1, 1) == "(") {
+                    # String interpolation: the expression nests like parentheses
+                    i++
+                    in_str = 0
+                    interp[++nint] = ++depth
+                } else if (c == "\\") i++
+                else if (c == "\"") in_str = 0
+                continue
+            }
+            if (c == ")" && nint > 0 && depth == interp[nint]) {
+                depth--
+                nint--
+                in_str = 1
+                continue
+            }
+            if (c == "\"") {
+                in_str = 1
+            } else if (c == "(" || c == "[" || c == "{") {
+                depth++
+            } else if (c == ")" || c == "]" || c == "}") {
+                depth--
+            } else if (c ~ /[a-z]/ && (i == 1 || substr(q, i - 1, 1) ~ /[ (|;,]/)) {
+                match(substr(q, i), /^[a-z_]+/)
+                w = substr(q, i, RLENGTH)
+                if (w == "if") {
+                    depth++
+                    if (depth == 1) {
+                        kind = "C"
+                        start = i + 2
+                    }
+                } else if (depth == 1 && (w == "then" || w == "elif" || w == "else" || w == "end")) {
+                    part[++n] = kind ":" trim(substr(q, start, i - start))
+                    kind = (w == "then") ? "T" : ((w == "elif") ? "C" : "E")
+                    start = i + RLENGTH
+                    if (w == "end") {
+                        # The closing end must finish the query
+                        if (i + RLENGTH - 1 != length(q)) exit 1
+                        for (k = 1; k <= n; k++) print part[k]
+                        exit 0
+                    }
+                } else if (w == "end") {
+                    depth--
+                }
+                i += RLENGTH - 1
+            }
+        }
+        exit 1
+    }
+    '
+}
+
+# Split a whole double-quoted string literal into one line per part: S for
+# text (escapes are kept) and E for an interpolated \(expression)
+# Returns 1 when the query is not a single string literal
+_yq_string_parts() {
+    printf '%s' "$1" | awk '
+    {
+        q = (NR == 1) ? $0 : q "\n" $0
+    }
+    # Offset of the quote closing the string opened at i, 0 when unterminated
+    function str_end(i) {
+        for (i++; i <= length(q); i++) {
+            c = substr(q, i, 1)
+            if (c == "\\" && substr(q, i + 1, 1) == "(") {
+                i = interp_end(i + 2)
+                if (!i) return 0
+            } else if (c == "\\") {
+                i++
+            } else if (c == "\"") {
+                return i
+            }
+        }
+        return 0
+    }
+    # Offset of the parenthesis closing an interpolation starting at i
+    function interp_end(i,    d) {
+        d = 1
+        for (; i <= length(q); i++) {
+            c = substr(q, i, 1)
+            if (c == "\"") {
+                i = str_end(i)
+                if (!i) return 0
+            } else if (c == "(") {
+                d++
+            } else if (c == ")" && --d == 0) {
+                return i
+            }
+        }
+        return 0
+    }
+    END {
+        if (substr(q, 1, 1) != "\"" || str_end(1) != length(q)) exit 1
+        text = ""
+        for (i = 2; i < length(q); i++) {
+            c = substr(q, i, 1)
+            if (c == "\\" && substr(q, i + 1, 1) == "(") {
+                if (text != "") print "S" text
+                text = ""
+                e = interp_end(i + 2)
+                print "E" substr(q, i + 2, e - i - 2)
+                i = e
+            } else if (c == "\\") {
+                text = text c substr(q, i + 1, 1)
+                i++
+            } else {
+                text = text c
+            }
+        }
+        if (text != "") print "S" text
+    }
+    '
+}
+
+# Split an arithmetic expression on its loosest top-level operator: the last
+# + or -, or when there is none the last *, / or %. * may carry merge flags
+# (*+, *d, *?, *n, *c). Sets _yq_arith_op, _yq_arith_flags, _yq_arith_left
+# and _yq_arith_right, returns 1 when there is no operator
+_yq_split_arith() {
+    _yq_arith_split=$(printf '%s' "$1" | awk '
+    {
+        q = (NR == 1) ? $0 : q "\n" $0
+    }
+    END {
+        depth = 0
+        in_str = 0
+        add_pos = 0
+        mul_pos = 0
+        for (i = 1; i <= length(q); i++) {
+            c = substr(q, i, 1)
+            if (in_str) {
+                if (c == "\\" && substr(q, i + 1, 1) == "(") {
+                    # String interpolation: the expression nests like parentheses
+                    i++
+                    in_str = 0
+                    interp[++nint] = ++depth
+                } else if (c == "\\") i++
+                else if (c == "\"") in_str = 0
+                continue
+            }
+            if (c == ")" && nint > 0 && depth == interp[nint]) {
+                depth--
+                nint--
+                in_str = 1
+                continue
+            }
+            if (c == "\"") {
+                in_str = 1
+            } else if (c == "(" || c == "[" || c == "{") {
+                depth++
+            } else if (c == ")" || c == "]" || c == "}") {
+                depth--
+            } else if (c ~ /[a-z]/ && (i == 1 || substr(q, i - 1, 1) ~ /[ (|;,]/)) {
+                match(substr(q, i), /^[a-z_]+/)
+                w = substr(q, i, RLENGTH)
+                if (w == "if") {
+                    depth++
+                    kw++
+                } else if (w == "end" && kw > 0) {
+                    depth--
+                    kw--
+                }
+                i += RLENGTH - 1
+            } else if (depth == 0 && c == " ") {
+                op3 = substr(q, i, 3)
+                if (op3 == " + " || op3 == " - ") {
+                    add_pos = i
+                    add_op = substr(op3, 2, 1)
+                } else if (op3 == " / " || op3 == " % ") {
+                    mul_pos = i
+                    mul_op = substr(op3, 2, 1)
+                    mul_len = 3
+                    mul_flags = ""
+                } else if (substr(q, i, 2) == " *" && match(substr(q, i + 2), /^[+d?nc]* /)) {
+                    mul_pos = i
+                    mul_op = "*"
+                    mul_len = 2 + RLENGTH
+                    mul_flags = substr(q, i + 2, RLENGTH - 1)
+                }
+            }
+        }
+        if (add_pos > 0) {
+            pos = add_pos; op = add_op; len = 3; flags = ""
+        } else if (mul_pos > 0) {
+            pos = mul_pos; op = mul_op; len = mul_len; flags = mul_flags
+        } else {
+            exit
+        }
+        printf "%s %d %d %s", op, pos, len, flags
+    }
+    ')
+    [ -z "$_yq_arith_split" ] && return 1
+
+    set -f
+    set -- "$1" $_yq_arith_split
+    set +f
+    _yq_arith_op="$2"
+    _yq_arith_flags="$5"
+    _yq_arith_left=$(printf '%s' "$1" | awk -v n="$3" '
+    { q = (NR == 1) ? $0 : q "\n" $0 }
+    END { q = substr(q, 1, n - 1); gsub(/^[[:space:]]+|[[:space:]]+$/, "", q); printf "%s", q }
+    ')
+    _yq_arith_right=$(printf '%s' "$1" | awk -v n="$3" -v len="$4" '
+    { q = (NR == 1) ? $0 : q "\n" $0 }
+    END { q = substr(q, n + len); gsub(/^[[:space:]]+|[[:space:]]+$/, "", q); printf "%s", q }
+    ')
+    return 0
+}
+
+# Run yq_parse and make sure its output ends with a newline, so that the
+# blank line printed between results always separates them
+# Runs in a subshell: nested calls cannot clobber the temp file variable
+_yq_parse_result() (
+    _result_tmp=$(mktemp -p "$_YQ_TEMP_DIR")
+    yq_parse "$1" "$2" > "$_result_tmp"
+    _result_status=$?
+    if [ -s "$_result_tmp" ]; then
+        cat "$_result_tmp"
+        [ -n "$(tail -c 1 "$_result_tmp")" ] && echo ""
+    fi
+    rm -f "$_result_tmp"
+    return $_result_status
+)
+
 # Parse and execute yq query recursively
 yq_parse() {
     _query="$1"
@@ -25,6 +392,12 @@ yq_parse() {
 
     # Increment depth for this call
     _yq_parse_depth=$((_yq_parse_depth + 1))
+
+    # The first call evaluates the root of the document
+    if [ -z "$_yq_ctx_root" ]; then
+        _yq_ctx_root="$_file"
+        _yq_ctx="."
+    fi
     [ -n "$POSIX_YQ_DEBUG" ] && _yq_debug_indent "$_yq_parse_depth" "yq_parse called with query='$_query'"
 
     # Check for recursive descent operator BEFORE removing leading dot
@@ -34,14 +407,112 @@ yq_parse() {
         return
     fi
 
+    # Variable binding (SRC as $name | BODY, SRC as $name ireduce (...))
+    # A pipe before the binding belongs to the outer expression
+    _as_pos=$(_yq_find_top_op "$_query" " as \$")
+    if [ "$_as_pos" -gt 0 ]; then
+        _as_pipe_pos=$(_yq_find_top_op "$_query" " | ")
+        if [ "$_as_pipe_pos" -eq 0 ] || [ "$_as_pos" -lt "$_as_pipe_pos" ]; then
+            yq_bind "$_query" "$_file"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+        fi
+    fi
+
+    # Comma operator: evaluate each part against the same input and emit the
+    # results one after another, separated by a blank line
+    # Pipes bind looser than commas, so only split when there is no top-level pipe
+    if [ "$(_yq_find_top_op "$_query" " | ")" -eq 0 ] && _yq_split_top "$_query" ","; then
+        [ -n "$POSIX_YQ_DEBUG" ] && _yq_debug_indent "$_yq_parse_depth" "Comma detected - Left: '$_yq_top_left' Right: '$_yq_top_right'"
+        eval "_comma_right_${_yq_parse_depth}=\"\$_yq_top_right\""
+        eval "_comma_file_${_yq_parse_depth}=\"\$_file\""
+        if ! _yq_parse_result "$_yq_top_left" "$_file" || _yq_raised; then
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return 1
+        fi
+        echo ""
+        eval "yq_parse \"\$_comma_right_${_yq_parse_depth}\" \"\$_comma_file_${_yq_parse_depth}\""
+        _comma_status=$?
+        _yq_parse_depth=$((_yq_parse_depth - 1))
+        return $_comma_status
+    fi
+
+    # Conditional: if C then T elif C then T else E end
+    case "$_query" in
+        if\ *|if\(*)
+            if _yq_if_parts "$_query" > /dev/null; then
+                yq_if "$_query" "$_file"
+                _if_status=$?
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return $_if_status
+            fi
+            ;;
+    esac
+
+    # Object construction: {key: expr, ...}
+    if [ "${_query#\{}" != "$_query" ] && _yq_is_group "$_query"; then
+        _obj_inner=$(printf '%s' "$_query" | sed 's/^{//; s/}[[:space:]]*$//')
+        yq_construct_object "$_obj_inner" "$_file"
+        _yq_parse_depth=$((_yq_parse_depth - 1))
+        return
+    fi
+
+    # Array construction: [expr] collects every result of expr
+    if [ "${_query#\[}" != "$_query" ] && _yq_is_group "$_query"; then
+        _col_inner=$(printf '%s' "$_query" | sed 's/^\[//; s/\][[:space:]]*$//')
+        yq_collect "$_col_inner" "$_file"
+        _yq_parse_depth=$((_yq_parse_depth - 1))
+        return
+    fi
+
+    # Literal scalars: numbers, booleans, null and double-quoted strings
+    case "$_query" in
+        true|false|null)
+            echo "$_query"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+    esac
+    if printf '%s\n' "$_query" | grep -q '^-\{0,1\}\([0-9][0-9]*\(\.[0-9]*\)\{0,1\}\|\.[0-9][0-9]*\)\([eE][-+]\{0,1\}[0-9][0-9]*\)\{0,1\}$'; then
+        echo "$_query"
+        _yq_parse_depth=$((_yq_parse_depth - 1))
+        return
+    fi
+    if _yq_string_parts "$_query" > /dev/null; then
+        yq_string_build "$_query" "$_file"
+        _yq_parse_depth=$((_yq_parse_depth - 1))
+        return
+    fi
+
+    # Variable reference: $name, optionally followed by a path ($name.key)
+    if printf '%s\n' "$_query" | grep -q '^\$[a-zA-Z_][a-zA-Z0-9_]*\(\.[a-zA-Z0-9_.]*\|\[[^]]*\]\)*$'; then
+        _var_name=$(printf '%s' "$_query" | sed 's/^\$\([a-zA-Z0-9_]*\).*/\1/')
+        _var_path=$(printf '%s' "$_query" | sed 's/^\$[a-zA-Z0-9_]*//')
+        eval "_var_file=\${_yq_var_${_var_name}:-}"
+        if [ -z "$_var_file" ]; then
+            _yq_error "variable \$$_var_name is not defined"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return 1
+        fi
+        if [ -z "$_var_path" ]; then
+            cat "$_var_file"
+        else
+            yq_parse ".${_var_path#.}" "$_var_file"
+        fi
+        _yq_parse_depth=$((_yq_parse_depth - 1))
+        return
+    fi
+
     # Check for parentheses - but only if they wrap the entire expression
     # Need to handle cases like (.foo) | .bar differently from just (.foo)
-    if echo "$_query" | grep -q '^([^)]*)'$; then
-        # Just parentheses, no pipe after
-        _inner=$(echo "$_query" | sed 's/^(//' | sed 's/)$//')
+    if [ "${_query#(}" != "$_query" ] && _yq_is_group "$_query"; then
+        # Just parentheses, no pipe after; the group may nest its own
+        # parentheses and pipes, e.g. (.b | error("e"))
+        _inner=$(printf '%s' "$_query" | sed 's/^(//; s/)[[:space:]]*$//')
         yq_parse "$_inner" "$_file"
+        _group_status=$?
         _yq_parse_depth=$((_yq_parse_depth - 1))
-        return
+        return $_group_status
     elif echo "$_query" | grep -q '^([^)]*) | '; then
         # Parentheses followed by pipe
         _paren_part=$(echo "$_query" | sed 's/^\(([^)]*)\).*/\1/')
@@ -82,6 +553,7 @@ yq_parse() {
             # Check if alternative is a literal value
             if [ "$_after_alt" = "[]" ]; then
                 # Empty array - return nothing
+                _yq_parse_depth=$((_yq_parse_depth - 1))
                 return
             elif [ "$_after_alt" = "null" ]; then
                 printf "null"
@@ -100,7 +572,8 @@ yq_parse() {
 
     # Check for functions with arguments BEFORE pipes (so pipes inside function args aren't split)
     # Pattern: functionname(args)
-    if echo "$_query" | grep -q '^[a-zA-Z_][a-zA-Z0-9_]*('; then
+    # A call followed by a top-level pipe (select(...) | .x) is left to the pipe handler
+    if echo "$_query" | grep -q '^[a-zA-Z_][a-zA-Z0-9_]*(' && [ "$(_yq_find_top_op "$_query" " | ")" -eq 0 ]; then
         _func_name=$(echo "$_query" | sed 's/(.*//')
         _func_args=$(echo "$_query" | sed 's/^[^(]*//' | sed 's/^(//' | sed 's/)$//')
 
@@ -109,33 +582,145 @@ yq_parse() {
                 # Remove quotes from argument
                 _key=$(echo "$_func_args" | sed 's/^"\(.*\)"$/\1/')
                 yq_has "$_key" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
                 return
                 ;;
             "map")
                 yq_map "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
                 return
                 ;;
             "select")
                 yq_select "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "join")
+                _sep=$(echo "$_func_args" | sed 's/^"\(.*\)"$/\1/')
+                yq_join "$_sep" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
                 return
                 ;;
             "del")
                 yq_del "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "path")
+                yq_path "$_func_args" "$_file"
+                _path_fn_status=$?
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return $_path_fn_status
+                ;;
+            "paths")
+                yq_paths "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "getpath")
+                yq_getpath "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "setpath")
+                _yq_split_top "$_func_args" ";"
+                yq_setpath "$_yq_top_left" "$_yq_top_right" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "delpaths")
+                yq_delpaths "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "pick"|"omit")
+                yq_pick "$_func_name" "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "first")
+                yq_first_match "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "nth")
+                yq_parse ".[$(_yq_parse_result "$_func_args" "$_file" | tr -d '[:space:]')]" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "format_datetime"|"tz")
+                _dt_arg=$(mktemp -p "$_YQ_TEMP_DIR")
+                _yq_parse_result "$_func_args" "$_file" > "$_dt_arg"
+                if [ "$_func_name" = "tz" ]; then
+                    yq_datetime tz "$(yq_scalar_text "$_dt_arg")" "$_file"
+                else
+                    yq_datetime format "$(yq_scalar_text "$_dt_arg")" "$_file"
+                fi
+                _dt_status=$?
+                rm -f "$_dt_arg"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return $_dt_status
+                ;;
+            "with_dtf")
+                yq_with_dtf "$_func_args" "$_file"
+                _dt_status=$?
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return $_dt_status
+                ;;
+            "to_json")
+                yq_to_json "$(_yq_parse_result "$_func_args" "$_file")" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "limit")
+                _yq_split_top "$_func_args" ";"
+                yq_limit "$_yq_top_left" "$_yq_top_right" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "range")
+                yq_range "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "any_c"|"all_c")
+                yq_any_all "${_func_name%_c}" "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "index"|"rindex"|"indices")
+                yq_indices "$_func_name" "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return
+                ;;
+            "parent")
+                yq_parent "$_func_args"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
                 return
                 ;;
+            "error")
+                yq_raise "$_func_args" "$_file"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return 1
+                ;;
         esac
     fi
 
     # Check for pipe operator (split on | and process sequentially)
-    if echo "$_query" | grep -q ' | \|^| '; then
+    # A pipe nested in parentheses, brackets or strings belongs to that group
+    _pipe_split=0
+    if echo "$_query" | grep -q '^| '; then
         # Handle case where query starts with pipe
-        if echo "$_query" | grep -q '^| '; then
-            _before_pipe="."
-            _after_pipe=$(echo "$_query" | sed 's/^| //')
-        else
-            _before_pipe=$(echo "$_query" | sed 's/ |.*//')
-            _after_pipe=$(echo "$_query" | sed 's/^[^|]* | //')
-        fi
+        _before_pipe="."
+        _after_pipe=$(echo "$_query" | sed 's/^| //')
+        _pipe_split=1
+    elif _yq_split_top "$_query" " | "; then
+        # Split on the first pipe outside brackets and strings
+        _before_pipe="$_yq_top_left"
+        _after_pipe="$_yq_top_right"
+        _pipe_split=1
+    fi
+    if [ "$_pipe_split" -eq 1 ]; then
 
         [ -n "$POSIX_YQ_DEBUG" ] && _yq_debug_indent "$_yq_parse_depth" "Pipe detected - Before: '$_before_pipe' After: '$_after_pipe'"
 
@@ -146,13 +731,22 @@ yq_parse() {
             return
         fi
 
-        # Check if left side ends with .[] (iteration)
-        # If so, we need to apply right side to each item separately
-        if echo "$_before_pipe" | grep -q '\[\]$'; then
+        # Check if left side ends with .[] (iteration) or has a top-level comma
+        # If so, it yields several results and the right side applies to each
+        if echo "$_before_pipe" | grep -q '\[\]?\{0,1\}$' || [ "$(_yq_find_top_op "$_before_pipe" ",")" -gt 0 ]; then
             [ -n "$POSIX_YQ_DEBUG" ] && _yq_debug_indent "$_yq_parse_depth" "Iteration handler: processing items with remainder: '$_after_pipe'"
             # Create unique state files for this iteration level
             # Each level gets its own state file that won't be clobbered by nested calls
             _iter_state=$(mktemp -p "$_YQ_TEMP_DIR")
+            eval "_saved_iter_status_${_yq_parse_depth}=0"
+
+            # Paths of the items in the document, for the context of each item
+            eval "_saved_ctx_${_yq_parse_depth}=\$_yq_ctx"
+            _iter_paths=$(mktemp -p "$_YQ_TEMP_DIR")
+            if [ -z "$_yq_ctx" ] || ! yq_expand_path "${_before_pipe%\?}" "$_file" > "$_iter_paths" 2>/dev/null; then
+                : > "$_iter_paths"
+            fi
+            eval "_saved_iter_paths_${_yq_parse_depth}='$_iter_paths'"
 
             # Process left side to get items
             _tmp_pipe=$(mktemp -p "$_YQ_TEMP_DIR")
@@ -228,6 +822,7 @@ yq_parse() {
                 eval "_saved_iter_base_${_yq_parse_depth}='$_iter_tmp_items'"
                 eval "_saved_iter_query_${_yq_parse_depth}='$_after_pipe'"
                 eval "_saved_iter_count_${_yq_parse_depth}='$_num_items'"
+                eval "_saved_iter_emitted_${_yq_parse_depth}=0"
 
                 # Use local loop counter variable unique to this depth
                 _loop_iter_var="_loop_idx_${_yq_parse_depth}"
@@ -237,6 +832,12 @@ yq_parse() {
                 [ -n "$POSIX_YQ_DEBUG" ] && _yq_debug_indent "$_yq_parse_depth" "Iteration: seq command will produce: $(seq 1 $_num_items | tr '\n' ' ')"
                 _loop_idx=0
                 for _item_idx in $(seq 1 $_num_items); do
+                    # An error aborts the remaining items
+                    if _yq_raised; then
+                        eval "_saved_iter_status_${_yq_parse_depth}=1"
+                        break
+                    fi
+
                     # Read from depth-specific variables
                     eval "_current_iter_base=\$_saved_iter_base_${_yq_parse_depth}"
                     eval "_current_iter_query=\$_saved_iter_query_${_yq_parse_depth}"
@@ -245,15 +846,27 @@ yq_parse() {
                     _loop_idx=$((_loop_idx + 1))
                     [ -n "$POSIX_YQ_DEBUG" ] && _yq_debug_indent "$_yq_parse_depth" "Iteration: loop iteration $_loop_idx (processing item $_item_idx)"
 
+                    # Items only have a context when each matches one path
+                    eval "_current_iter_paths=\$_saved_iter_paths_${_yq_parse_depth}"
+                    _yq_ctx=""
+                    if [ "$(wc -l < "$_current_iter_paths")" -eq "$_current_iter_count" ]; then
+                        eval "_yq_ctx=\$(_yq_ctx_join \"\$_saved_ctx_${_yq_parse_depth}\" \"\$(sed -n \"${_item_idx}p\" \"\$_current_iter_paths\")\")"
+                    fi
+
                     if [ -f "$_current_iter_base.$_item_idx" ]; then
                         [ -n "$POSIX_YQ_DEBUG" ] && _yq_debug_indent "$_yq_parse_depth" "Iteration: item #$_item_idx - calling yq_parse with query: '$_current_iter_query'"
 
-                        # Process the item (output goes directly to stdout)
-                        # Add a separator line between iterations
-                        if [ "$_item_idx" -gt 1 ]; then
-                            echo ""
+                        # Process the item, adding a separator line between the
+                        # outputs of iterations; items without results (empty) add none
+                        _yq_parse_result "$_current_iter_query" "$_current_iter_base.$_item_idx" > "$_current_iter_base.$_item_idx.out" ||
+                            eval "_saved_iter_status_${_yq_parse_depth}=1"
+                        if [ -s "$_current_iter_base.$_item_idx.out" ]; then
+                            eval "_current_iter_emitted=\$_saved_iter_emitted_${_yq_parse_depth}"
+                            [ "$_current_iter_emitted" = "1" ] && echo ""
+                            cat "$_current_iter_base.$_item_idx.out"
+                            eval "_saved_iter_emitted_${_yq_parse_depth}=1"
                         fi
-                        yq_parse "$_current_iter_query" "$_current_iter_base.$_item_idx"
+                        rm -f "$_current_iter_base.$_item_idx.out"
 
                         [ -n "$POSIX_YQ_DEBUG" ] && _yq_debug_indent "$_yq_parse_depth" "Iteration: item #$_item_idx - yq_parse returned"
                         rm -f "$_current_iter_base.$_item_idx"
@@ -267,9 +880,12 @@ yq_parse() {
 
                 rm -f "$_iter_state"* "$_iter_tmp_items"
             fi
+            eval "_yq_ctx=\$_saved_ctx_${_yq_parse_depth}"
+            eval "rm -f \"\$_saved_iter_paths_${_yq_parse_depth}\""
             rm -f "$_tmp_pipe"
+            eval "_iter_status=\$_saved_iter_status_${_yq_parse_depth}"
             _yq_parse_depth=$((_yq_parse_depth - 1))
-            return
+            return $_iter_status
         fi
 
         # Standard pipe processing (no iteration)
@@ -278,87 +894,257 @@ yq_parse() {
         _std_before_pipe="$_before_pipe"
         _std_after_pipe="$_after_pipe"
 
+        # The right side runs in the context of the left side's result
+        eval "_saved_ctx_${_yq_parse_depth}=\$_yq_ctx"
+        eval "_pipe_ctx_${_yq_parse_depth}=\$(_yq_ctx_for \"\$_std_before_pipe\" \"\$_file\")"
+
         _tmp_pipe=$(mktemp -p "$_YQ_TEMP_DIR")
         yq_parse "$_std_before_pipe" "$_file" > "$_tmp_pipe"
 
-        # Process second part with result from first part
-        yq_parse "$_std_after_pipe" "$_tmp_pipe"
+        # Process second part with result from first part; a left side
+        # without results (select, empty) leaves nothing to process
+        eval "_yq_ctx=\$_pipe_ctx_${_yq_parse_depth}"
+        _std_status=0
+        if _yq_raised; then
+            _std_status=1
+        elif [ -s "$_tmp_pipe" ]; then
+            yq_parse "$_std_after_pipe" "$_tmp_pipe"
+            _std_status=$?
+        fi
+        eval "_yq_ctx=\$_saved_ctx_${_yq_parse_depth}"
         rm -f "$_tmp_pipe"
         _yq_parse_depth=$((_yq_parse_depth - 1))
-        return
+        return $_std_status
     fi
 
-    # Check for arithmetic operators (-, *, /) - these are definitely not concatenation
-    if echo "$_query" | grep -q ' - \| \* \| / '; then
-        yq_arithmetic "$_query" "$_file"
-        _yq_parse_depth=$((_yq_parse_depth - 1))
-        return
-    fi
-
-    # Check for addition/concatenation with +
-    # If it's a simple expr + number, treat as arithmetic
-    # Otherwise, treat as string concatenation
-    if echo "$_query" | grep -q ' + '; then
-        # Check if there's only one + and right side is a number (arithmetic case)
-        if ! echo "$_query" | grep -q '.* + .* + '; then
-            _test_left=$(echo "$_query" | sed 's/ +.*//')
-            _test_right=$(echo "$_query" | sed 's/.* + //')
-
-            # If right side is just a number, might be arithmetic
-            if echo "$_test_right" | grep -q '^[0-9]\+$'; then
-                yq_arithmetic "$_query" "$_file"
-                _yq_parse_depth=$((_yq_parse_depth - 1))
-                return
+    # Error handling: try BODY [catch HANDLER]
+    case "$_query" in
+        try\ *|try\(*)
+            _try_handler=""
+            _try_body="${_query#try}"
+            if _yq_split_top "$_query" " catch "; then
+                _try_body="${_yq_top_left#try}"
+                _try_handler="$_yq_top_right"
             fi
-        fi
-
-        # Otherwise, split by + and evaluate each part, then concatenate
-        _result=""
-        _remaining="$_query"
+            _try_body=$(printf '%s' "$_try_body" | sed 's/^[[:space:]]*//')
+            yq_try "$_try_body" "$_try_handler" "$_file"
+            _try_status=$?
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return $_try_status
+            ;;
+    esac
 
-        while echo "$_remaining" | grep -q ' + '; do
-            _part=$(echo "$_remaining" | sed 's/ +.*//')
-            _remaining=$(echo "$_remaining" | sed 's/^[^+]* + //')
+    # Optional suffix: .a[]? and .b? drop the errors of the expression
+    case "$_query" in
+        *\?)
+            yq_try "${_query%\?}" "" "$_file"
+            _try_status=$?
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return $_try_status
+            ;;
+    esac
 
-            # Evaluate this part
-            if echo "$_part" | grep -q '^".*"$'; then
-                # String literal
-                _part_value=$(echo "$_part" | sed 's/^"\(.*\)"$/\1/')
-            else
-                # Expression - evaluate it
-                _tmp_concat=$(mktemp -p "$_YQ_TEMP_DIR")
-                yq_parse "$_part" "$_file" > "$_tmp_concat"
-                _part_value=$(cat "$_tmp_concat")
-                # Remove quotes if present
-                _part_value=$(echo "$_part_value" | sed 's/^"\(.*\)"$/\1/')
-                rm -f "$_tmp_concat"
+    # Style assignment: .a style="double", .. style="flow"
+    case " $_query" in
+        *" style="*|*" style = "*)
+            if _yq_split_top " $_query" " style" && [ "${_yq_top_right#=}" != "$_yq_top_right" ] && [ "${_yq_top_right#==}" = "$_yq_top_right" ]; then
+                # Only a path may come before style, .s |= style="single"
+                # is an update whose right side sets the style of .
+                case "${_yq_top_left# }" in
+                    *[" |=,"]*|[!.]*) ;;
+                    *)
+                        _style_rhs=$(printf '%s' "${_yq_top_right#=}" | sed 's/^[[:space:]]*//')
+                        yq_set_style "${_yq_top_left:-.}" "$_style_rhs" "$_file"
+                        _style_status=$?
+                        _yq_parse_depth=$((_yq_parse_depth - 1))
+                        return $_style_status
+                        ;;
+                esac
             fi
+            ;;
+    esac
 
-            _result="${_result}${_part_value}"
-        done
+    # Compound assignment: .a += x, -=, *=, /=, %= and //=
+    case "$_query" in
+        *" += "*|*" -= "*|*" *= "*|*" /= "*|*" %= "*|*" //= "*)
+            for _cmp_op in "//" "+" "-" "*" "/" "%"; do
+                if _yq_split_top "$_query" " $_cmp_op= "; then
+                    yq_compound_assign "$_cmp_op" "$_yq_top_left" "$_yq_top_right" "$_file"
+                    _cmp_status=$?
+                    _yq_parse_depth=$((_yq_parse_depth - 1))
+                    return $_cmp_status
+                fi
+            done
+            ;;
+    esac
 
-        # Process last part
-        if echo "$_remaining" | grep -q '^".*"$'; then
-            # String literal
-            _part_value=$(echo "$_remaining" | sed 's/^"\(.*\)"$/\1/')
-        else
-            # Expression
-            _tmp_concat=$(mktemp -p "$_YQ_TEMP_DIR")
-            yq_parse "$_remaining" "$_file" > "$_tmp_concat"
-            _part_value=$(cat "$_tmp_concat")
-            # Remove quotes if present
-            _part_value=$(echo "$_part_value" | sed 's/^"\(.*\)"$/\1/')
-            rm -f "$_tmp_concat"
-        fi
-        _result="${_result}${_part_value}"
+    # Arithmetic: + - * / % on numbers, + and - on collections (arrays
+    # concatenate or drop the items of the right side, maps merge) and the *
+    # deep merge with its flags. Comparisons and assignments bind looser
+    case "$_query" in
+        *" == "*|*" != "*|*" = "*|*" |= "*) ;;
+        *" + "*|*" - "*|*" *"*|*" / "*|*" % "*)
+            if _yq_split_arith "$_query"; then
+                _arith_base=$(mktemp -p "$_YQ_TEMP_DIR")
+                _yq_parse_result "$_yq_arith_left" "$_file" > "$_arith_base.l"
+                _yq_parse_result "$_yq_arith_right" "$_file" > "$_arith_base.r"
+                _arith_kinds="$(_yq_operand_kind "$_arith_base.l") $(_yq_operand_kind "$_arith_base.r")"
+                case "$_yq_arith_op:$_arith_kinds" in
+                    "+:"*seq*|"+:"*map*)
+                        yq_add "$_arith_base.l" "$_arith_base.r"
+                        ;;
+                    "-:"*seq*|"-:"*map*)
+                        yq_subtract "$_arith_base.l" "$_arith_base.r"
+                        ;;
+                    "*:"*seq*|"*:"*map*)
+                        yq_merge "$_arith_base.l" "$_arith_base.r" "$_yq_arith_flags"
+                        ;;
+                    *)
+                        if [ -n "$_yq_arith_flags" ]; then
+                            yq_merge "$_arith_base.l" "$_arith_base.r" "$_yq_arith_flags"
+                        else
+                            yq_arithmetic "$_yq_arith_op" "$_arith_base.l" "$_arith_base.r"
+                        fi
+                        ;;
+                esac
+                _arith_status=$?
+                rm -f "$_arith_base"*
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return $_arith_status
+            fi
+            ;;
+    esac
 
-        echo "$_result"
-        _yq_parse_depth=$((_yq_parse_depth - 1))
-        return
-    fi
+    # Unary minus: -.a, -(expr), -$var (binary operators were split above)
+    case "$_query" in
+        -.*|-\(*|-\$*)
+            _neg_base=$(mktemp -p "$_YQ_TEMP_DIR")
+            echo 0 > "$_neg_base.zero"
+            _yq_parse_result "${_query#-}" "$_file" > "$_neg_base.value"
+            yq_arithmetic "-" "$_neg_base.zero" "$_neg_base.value"
+            rm -f "$_neg_base"*
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+    esac
+
+    # Functions named like common keys (.key, .line, .error) are only
+    # calls when written without the leading dot
+    case "$_query" in
+        "empty")
+            # Produces no result at all
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "paths")
+            yq_paths "" "$_file"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "error")
+            # The input itself is the error message
+            yq_raise "." "$_file"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return 1
+            ;;
+        "path")
+            # Without an argument: the path of the current node
+            if [ -n "$_yq_ctx" ]; then
+                yq_path_to_seq "$_yq_ctx"
+            else
+                echo "null"
+            fi
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "first"|"last")
+            [ "$_query" = "first" ] && _nth="[0]" || _nth="[-1]"
+            yq_parse ".$_nth" "$_file"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "any"|"all")
+            yq_any_all "$_query" "." "$_file"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "key")
+            yq_key
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "parent")
+            yq_parent 1
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "line"|"column")
+            [ "$_query" = "line" ] && yq_line 1 || yq_line 2
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "filename")
+            if [ -n "$_yq_filename" ]; then
+                yq_string_literal "\"$_yq_filename\""
+            else
+                echo "null"
+            fi
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "fileIndex")
+            echo "${_yq_file_index:-0}"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "now")
+            yq_now
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "to_unix"|"from_unix")
+            yq_datetime "$_query" "" "$_file"
+            _dt_status=$?
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return $_dt_status
+            ;;
+        @*)
+            yq_encode "${_query#@}" "$_file"
+            _enc_status=$?
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return $_enc_status
+            ;;
+        "style")
+            yq_style "$_file"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+        "to_number"|"to_string"|"tostring"|"to_bool"|"tojson")
+            case "$_query" in
+                "to_number") yq_to_number "$_file" ;;
+                "to_bool") yq_to_bool "$_file" ;;
+                "tojson") yq_to_json 0 "$_file" ;;
+                *) yq_to_string "$_file" ;;
+            esac
+            _conv_status=$?
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return $_conv_status
+            ;;
+        "to_json"|"to_yaml"|"from_json"|"from_yaml")
+            case "$_query" in
+                "to_json") yq_to_json 2 "$_file" ;;
+                "to_yaml") yq_to_yaml "$_file" ;;
+                "from_json") yq_from_json "$_file" ;;
+                "from_yaml") yq_from_yaml "$_file" ;;
+            esac
+            _enc_status=$?
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return $_enc_status
+            ;;
+    esac
 
     # Remove leading dot
-    _query=$(echo "$_query" | sed 's/^\.//')
+    _query=$(printf '%s\n' "$_query" | sed 's/^\.//')
 
     # Handle empty query (identity)
     if [ -z "$_query" ]; then
@@ -384,6 +1170,11 @@ yq_parse() {
             _yq_parse_depth=$((_yq_parse_depth - 1))
             return
             ;;
+        "sort")
+            yq_sort "$_file"
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
     esac
 
     # Check for assignment operator (=)
@@ -402,6 +1193,10 @@ yq_parse() {
 
     # Check for comparison operators (==, !=, etc.)
     if echo "$_query" | grep -q ' == \| != '; then
+        # Restore the dot of .[] so the left side is not read as [] construction
+        case "$_query" in
+            \[*) _query=".$_query" ;;
+        esac
         yq_compare "$_query" "$_file"
         _yq_parse_depth=$((_yq_parse_depth - 1))
         return
@@ -433,8 +1228,23 @@ yq_parse() {
         return
     fi
 
-    # Remove leading dot from remainder
-    _remainder=$(echo "$_remainder" | sed 's/^\.//')
+    # An optional token inside a path (.a[]?.b) reads as (.a[])? | .b
+    case "$_remainder" in
+        \?*)
+            _opt_rest=$(echo "$_remainder" | sed 's/^?//; s/^\.//')
+            _opt_query=".$_first_token?"
+            if [ -n "$_opt_rest" ]; then
+                yq_parse "$_opt_query | .$_opt_rest" "$_file"
+            else
+                yq_parse "$_opt_query" "$_file"
+            fi
+            _yq_parse_depth=$((_yq_parse_depth - 1))
+            return
+            ;;
+    esac
+
+    # The remainder stays a path (.[] rather than [], which would construct an array)
+    _remainder=$(echo "$_remainder" | sed 's/^\.//; s/^./.&/')
 
     # Apply the first token operation
     _tmp_result=$(mktemp -p "$_YQ_TEMP_DIR")
@@ -442,7 +1252,11 @@ yq_parse() {
     case "$_first_token" in
         "[]")
             # Array/object iteration
-            yq_iterate "$_file" > "$_tmp_result"
+            if ! yq_iterate "$_file" > "$_tmp_result"; then
+                rm -f "$_tmp_result"
+                _yq_parse_depth=$((_yq_parse_depth - 1))
+                return 1
+            fi
             ;;
         \[*\])
             # Array access (index or slice)
@@ -455,21 +1269,47 @@ yq_parse() {
     esac
 
     # If there's a remainder, recursively process each result
+    eval "_path_status_${_yq_parse_depth}=0"
     if [ -n "$_remainder" ]; then
         # Check if tmp_result has content
         if [ -s "$_tmp_result" ]; then
             # For iteration results, process each item separately
+            # Items are split from the original node so multi-line items
+            # (maps, nested sequences) stay whole, e.g. .a[][] or .[].name
             if [ "$_first_token" = "[]" ]; then
-                _line_num=0
-                while IFS= read -r _line || [ -n "$_line" ]; do
-                    _line_num=$((_line_num + 1))
-                    _item_file=$(mktemp -p "$_YQ_TEMP_DIR")
-                    echo "$_line" > "$_item_file"
-                    yq_parse "$_remainder" "$_item_file"
-                    rm -f "$_item_file"
-                done < "$_tmp_result"
+                _item_base=$(mktemp -p "$_YQ_TEMP_DIR")
+                _item_count=$(yq_split_items "$_file" "$_item_base")
+                eval "_saved_item_base_${_yq_parse_depth}='$_item_base'"
+                eval "_saved_item_count_${_yq_parse_depth}='$_item_count'"
+                eval "_saved_item_query_${_yq_parse_depth}=\"\$_remainder\""
+                eval "_item_idx_${_yq_parse_depth}=1"
+                eval "_saved_item_emitted_${_yq_parse_depth}=0"
+                while eval "[ \"\$_item_idx_${_yq_parse_depth}\" -le \"\$_saved_item_count_${_yq_parse_depth}\" ]"; do
+                    if _yq_raised; then
+                        eval "_path_status_${_yq_parse_depth}=1"
+                        break
+                    fi
+                    eval "_current_item_idx=\$_item_idx_${_yq_parse_depth}"
+                    eval "_current_item_base=\$_saved_item_base_${_yq_parse_depth}"
+                    eval "_current_item_query=\$_saved_item_query_${_yq_parse_depth}"
+                    # Items without results (empty) add no separator
+                    _yq_parse_result "$_current_item_query" "$_current_item_base.$_current_item_idx" > "$_current_item_base.$_current_item_idx.out" ||
+                        eval "_path_status_${_yq_parse_depth}=1"
+                    if [ -s "$_current_item_base.$_current_item_idx.out" ]; then
+                        eval "_current_item_emitted=\$_saved_item_emitted_${_yq_parse_depth}"
+                        [ "$_current_item_emitted" = "1" ] && echo ""
+                        cat "$_current_item_base.$_current_item_idx.out"
+                        eval "_saved_item_emitted_${_yq_parse_depth}=1"
+                    fi
+                    rm -f "$_current_item_base.$_current_item_idx.out"
+                    eval "_current_item_base=\$_saved_item_base_${_yq_parse_depth}"
+                    eval "_current_item_idx=\$_item_idx_${_yq_parse_depth}"
+                    rm -f "$_current_item_base.$_current_item_idx"
+                    eval "_item_idx_${_yq_parse_depth}=$((_current_item_idx + 1))"
+                done
+                eval "rm -f \"\$_saved_item_base_${_yq_parse_depth}\""
             else
-                yq_parse "$_remainder" "$_tmp_result"
+                yq_parse "$_remainder" "$_tmp_result" || eval "_path_status_${_yq_parse_depth}=1"
             fi
         fi
     else
@@ -477,7 +1317,9 @@ yq_parse() {
     fi
 
     rm -f "$_tmp_result"
+    eval "_path_status=\$_path_status_${_yq_parse_depth}"
     _yq_parse_depth=$((_yq_parse_depth - 1))
+    return $_path_status
 }
 `
 }
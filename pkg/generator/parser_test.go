@@ -233,3 +233,527 @@ func TestGenerateParserHandlesQuoteRemoval(t *testing.T) {
 		t.Error("Parser missing sed for quote handling")
 	}
 }
+
+// newYqParseTester returns a tester with every module yq_parse can dispatch to
+func newYqParseTester(t *testing.T) *ShellFunctionTester {
+	return NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateParser(),
+		GenerateCoreFunctions(),
+		GenerateAdvancedFunctions(),
+		GenerateOperators(),
+		GenerateJSON(),
+		GenerateProperties(),
+		GenerateCSV(),
+		GenerateEncoding(),
+		GenerateDatetime(),
+	)
+}
+
+// TestYqFindTopOp verifies operators nested in brackets or strings are skipped
+func TestYqFindTopOp(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		query    string
+		op       string
+		expected string
+	}{
+		{name: "top-level comma", query: ".a, .b", op: ",", expected: "3"},
+		{name: "comma in parentheses", query: "(.a, .b)", op: ",", expected: "0"},
+		{name: "comma in function call", query: "del(.a, .b)", op: ",", expected: "0"},
+		{name: "comma in string", query: `"a, b", .c`, op: ",", expected: "7"},
+		{name: "escaped quote in string", query: `"a\", b", .c`, op: ",", expected: "9"},
+		{name: "pipe after parentheses", query: "(.a | .b) | .c", op: " | ", expected: "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_find_top_op", tt.query, tt.op)
+		})
+	}
+}
+
+// TestYqParseCommaOperator verifies each comma-separated part yields its own result
+func TestYqParseCommaOperator(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "name: John\nage: 30\nitems:\n  - a: 1\n    b: 2\n  - a: 3\n    b: 4"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "top level", query: ".name, .age", expected: "John\n\n30"},
+		{name: "three parts", query: ".age, .name, .age", expected: "30\n\nJohn\n\n30"},
+		{name: "inside parentheses", query: "(.name, .age)", expected: "John\n\n30"},
+		{name: "binds tighter than pipe", query: ".name, .items | length", expected: "4\n\n2"},
+		{name: "after iteration", query: ".items[] | .a, .b", expected: "1\n\n2\n\n3\n\n4"},
+		{name: "inside map", query: ".items | map(.a, .b)", expected: "- 1\n- 2\n- 3\n- 4"},
+		{name: "inside del", query: "del(.name, .items)", expected: "age: 30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+// TestYqParseNestedPipe verifies a pipe inside parentheses stays in its group
+func TestYqParseNestedPipe(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "n: 1\nb:\n  - 1\n  - 2"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "assigned value", query: ".x = (.n | . + 1)", expected: "n: 1\nb:\n  - 1\n  - 2\nx: 2"},
+		{name: "arithmetic operand", query: "(.b | length) + 1", expected: "3"},
+		{name: "condition", query: "if (.b | length) == 2 then \"two\" else \"other\" end", expected: "two"},
+		{name: "object value", query: "{c: (.b | length)}", expected: "c: 2"},
+		{name: "top-level pipe after group", query: "(.b | length) | . * 2", expected: "4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+// TestYqParseObjectConstruction verifies {key: expr} builds mappings from the input
+func TestYqParseObjectConstruction(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "name: John\nage: 30\ntags:\n  - a\n  - b\nmeta:\n  k: v"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "quoted key", query: `{"n": .name}`, expected: "n: John"},
+		{name: "bare key", query: "{n: .name, a: .age}", expected: "n: John\na: 30"},
+		{name: "shorthand", query: "{name, age}", expected: "name: John\nage: 30"},
+		{name: "computed key", query: "{(.name): .age}", expected: "John: 30"},
+		{name: "literal values", query: `{a: 1, b: "x", c: "10"}`, expected: "a: 1\nb: x\nc: \"10\""},
+		{name: "nested sequence", query: "{t: .tags}", expected: "t:\n  - a\n  - b"},
+		{name: "nested map", query: "{m: .meta}", expected: "m:\n  k: v"},
+		{name: "cartesian product", query: "{t: .tags[], n: .name}", expected: "t: a\nn: John\n\nt: b\nn: John"},
+		{name: "empty object", query: "{}", expected: "{}"},
+		{name: "piped", query: "{n: .name} | .n", expected: "John"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+// TestYqParseArrayConstruction verifies [expr] collects results into a sequence
+func TestYqParseArrayConstruction(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "name: John\ntags:\n  - a\n  - b\nitems:\n  - name: x\n    v: 2\n  - name: y\n    v: 1"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "collect iteration", query: "[.items[].name]", expected: "- x\n- y"},
+		{name: "empty", query: "[]", expected: "[]"},
+		{name: "no results", query: "[.missing[]]", expected: "[]"},
+		{name: "maps", query: "[.items[]]", expected: "- name: x\n  v: 2\n- name: y\n  v: 1"},
+		{name: "nested", query: "[[.tags[]], .name]", expected: "- - a\n  - b\n- John"},
+		{name: "length", query: "[.items[].name] | length", expected: "2"},
+		{name: "join", query: `[.items[].name] | join(", ")`, expected: "x, y"},
+		{name: "sort", query: "[.items[].v] | sort", expected: "- 1\n- 2"},
+		{name: "map any expression", query: ".items | map({n: .name})", expected: "- n: x\n- n: y"},
+		{name: "iteration path unchanged", query: ".tags[]", expected: "a\n\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+// TestYqParseEmptyConstructionJSON verifies [] and {} stay collections in JSON
+func TestYqParseEmptyConstructionJSON(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "empty array", query: "[]", expected: "[]"},
+		{name: "empty map", query: "{}", expected: "{}"},
+		{name: "nested empty array", query: "[[]]", expected: "[[]]"},
+		{name: "empty values", query: `{"a": [], "b": {}}`, expected: `{"a":[],"b":{}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", "x: 1")
+			tester.ExecuteFunctionExpect(tt.expected, `_yq_to_json() { yq_yaml_to_json "$(yq_parse "$1" "$2")"; }; _yq_to_json`, tt.query, testFile)
+		})
+	}
+}
+
+// TestYqParseVariableBinding verifies "as $name" bindings, ireduce and *
+func TestYqParseVariableBinding(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "a: 1\nb: 2\n---\nb: 3\nc: 4"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "reduce documents with merge", query: ". as $item ireduce ({}; . * $item)", expected: "a: 1\nb: 3\nc: 4"},
+		{name: "bind and pipe", query: ". as $d ireduce ({}; . * $d) | .b as $x | {v: $x}", expected: "v: 3"},
+		{name: "variable path", query: ". as $d | $d.c", expected: "null\n\n4"},
+		{name: "merge literal", query: ". as $d ireduce ({}; . * $d) | . * {\"a\": 9}", expected: "a: 9\nb: 3\nc: 4"},
+		{name: "multiply numbers", query: ". as $d ireduce ({}; . * $d) | .b * .c", expected: "12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			// Single-quoted so the shell does not expand $name
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse '"+tt.query+"'", testFile)
+		})
+	}
+}
+
+// TestYqParseCollectionArithmetic verifies + and - on arrays and maps
+func TestYqParseCollectionArithmetic(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "pkgs:\n  - git\n  - curl\nm:\n  a: 1"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "concatenate", query: `.pkgs + ["jq"]`, expected: "- git\n- curl\n- jq"},
+		{name: "difference", query: `.pkgs - ["git"]`, expected: "- curl"},
+		{name: "left associative", query: `.pkgs + ["git"] - ["git"]`, expected: "- curl"},
+		{name: "map merge", query: `.m + {"b": 2}`, expected: "a: 1\nb: 2"},
+		{name: "append assignment", query: `.pkgs += ["jq"]`, expected: "pkgs:\n  - git\n  - curl\n  - jq\nm:\n  a: 1"},
+		{name: "remove assignment", query: `.pkgs -= ["curl"]`, expected: "pkgs:\n  - git\nm:\n  a: 1"},
+		{name: "string concatenation unchanged", query: `.m.a + "x"`, expected: "1x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+// TestYqParseArithmeticPrecedence verifies operator precedence and unary minus
+func TestYqParseArithmeticPrecedence(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "a: 3\nb: 1.5\nid: 12345678901234567890"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "multiplication first", query: ".a + 2 * 4", expected: "11"},
+		{name: "left associative", query: ".a - 1 - 1", expected: "1"},
+		{name: "parentheses", query: "(.a + 1) * .b", expected: "6"},
+		{name: "modulo", query: ".a % 2", expected: "1"},
+		{name: "unary minus", query: "-.a + 5", expected: "2"},
+		{name: "big id", query: ".id + 1", expected: "12345678901234567891"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+// TestYqParseCompoundAssignment verifies op= updates every matched path
+func TestYqParseCompoundAssignment(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "n: 2\nt: null\nstep: 3\nitems:\n  - c: 1\n  - c: 5"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "add", query: ".n += 1", expected: "n: 3\nt: null\nstep: 3\nitems:\n  - c: 1\n  - c: 5"},
+		{name: "subtract", query: ".n -= 3", expected: "n: -1\nt: null\nstep: 3\nitems:\n  - c: 1\n  - c: 5"},
+		{name: "multiply", query: ".n *= 1.5", expected: "n: 3\nt: null\nstep: 3\nitems:\n  - c: 1\n  - c: 5"},
+		{name: "divide", query: ".n /= 4", expected: "n: 0.5\nt: null\nstep: 3\nitems:\n  - c: 1\n  - c: 5"},
+		{name: "modulo", query: ".step %= 2", expected: "n: 2\nt: null\nstep: 1\nitems:\n  - c: 1\n  - c: 5"},
+		{name: "default", query: ".t //= 30", expected: "n: 2\nt: 30\nstep: 3\nitems:\n  - c: 1\n  - c: 5"},
+		{name: "default keeps value", query: ".n //= 30", expected: "n: 2\nt: null\nstep: 3\nitems:\n  - c: 1\n  - c: 5"},
+		{name: "every match, rhs from root", query: ".items[].c += .step", expected: "n: 2\nt: null\nstep: 3\nitems:\n  - c: 4\n  - c: 8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+func TestYqParseConditional(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "items:\n  - name: a\n    enabled: true\n    n: 1\n  - name: b\n    enabled: false\n    n: 5\n  - name: c\n    n: 12"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "then or empty", query: ".items[] | if .enabled then .name else empty end", expected: "a"},
+		{name: "elif chain", query: ".items[] | if .n == 5 then \"five\" elif .n == 1 then \"one\" else \"other\" end", expected: "one\n\nfive\n\nother"},
+		{name: "no else passes input", query: ".items[1] | if .enabled then .name end | .n", expected: "5"},
+		{name: "nested", query: ".items[2] | if .name == \"c\" then (if .n == 12 then \"c12\" else \"c\" end) else .name end", expected: "c12"},
+		{name: "collected", query: "[.items[] | if .enabled == false then .name else empty end]", expected: "- b"},
+		{name: "truthy map", query: "if .items then \"yes\" else \"no\" end", expected: "yes"},
+		{name: "empty", query: "empty", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+func TestYqParseErrorHandling(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "a: hello\nitems:\n  - tags:\n      - x\n      - y\n  - tags: none\n  - name: q"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "optional iteration", query: ".items[].tags[]?", expected: "x\n\ny"},
+		{name: "optional after pipe", query: ".a[]?", expected: ""},
+		{name: "optional inside path", query: ".items[].tags[]?.z", expected: "null\n\nnull"},
+		{name: "catch handler", query: "try .a[] catch \"bad\"", expected: "bad"},
+		{name: "catch receives message", query: "try .a[] catch .", expected: "cannot iterate over !!str"},
+		{name: "try without catch", query: "try error(\"boom\")", expected: ""},
+		{name: "catch error message", query: "try error(\"boom\") catch (\"caught \" + .)", expected: "caught boom"},
+		{name: "catch arithmetic error", query: "try (1 / 0) catch \"div\"", expected: "div"},
+		{name: "per item", query: "[.items[] | try .tags[] catch \"n/a\"]", expected: "- x\n- y\n- n/a"},
+		{name: "piped body error", query: "try (.a | error(\"e\")) catch \"c\"", expected: "c"},
+		{name: "piped body iteration", query: "try (.a | .[]) catch \"c\"", expected: "c"},
+		{name: "piped body results", query: "try (.items[0] | .tags[]) catch \"c\"", expected: "x\n\ny"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+
+	for _, query := range []string{".a[]", "error(\"boom\")", ".a | error", "(.a | error(\"e\"))"} {
+		t.Run("raises "+query, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpectError("_yq_init_temp_dir; yq_parse", query, testFile)
+		})
+	}
+
+	// error aborts the evaluation: it is reported once and nothing else runs
+	for _, query := range []string{
+		".items[] | error(\"x\")",
+		"[.items[] | error(\"x\")]",
+		".items[].name | error(\"x\")",
+		"error(\"x\"), .a",
+		"{\"k\": error(\"x\")} | .k",
+	} {
+		t.Run("aborts "+query, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect("Error: x", "_yq_init_temp_dir; yq_parse", query, testFile)
+		})
+	}
+}
+
+func TestYqParseContext(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "name: api\nspec:\n  image: nginx\n  ports:\n    - 80\n    - port: 443\n      proto: tcp\n  tags:\n    - a\n    - latest"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "key of a match", query: ".. | select(. == \"latest\") | key", expected: "1"},
+		{name: "key of entries", query: ".spec.ports[1][] | key", expected: "port\n\nproto"},
+		{name: "key field is not the function", query: ".spec | to_entries | .[0] | .key", expected: "image"},
+		{name: "parent", query: ".. | select(. == \"latest\") | parent | key", expected: "tags"},
+		{name: "parent levels", query: ".spec.ports[1].proto | parent(2) | key", expected: "ports"},
+		{name: "parent by key", query: ".. | select(key == \"proto\") | parent | .port", expected: "443"},
+		{name: "path of a match", query: ".. | select(. == 443) | path", expected: "- spec\n- ports\n- 1\n- port"},
+		{name: "line and column", query: ".. | select(. == \"latest\") | [line, column]", expected: "- 10\n- 7"},
+		{name: "lines of items", query: "[.spec.ports[] | line]", expected: "- 5\n- 6"},
+		{name: "root has no key", query: "key", expected: "null"},
+		{name: "constructed values have no line", query: "{\"a\": 1} | .a | line", expected: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+func TestYqParseSelectionHelpers(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "nums:\n  - 3\n  - 7\n  - 12\n  - 7\nflags:\n  - true\n  - false\nempty: []\ntext: \"a,b, cd, efg\"\nitems:\n  - name: a\n    on: false\n  - name: b\n    on: true"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "first", query: ".nums | first", expected: "3"},
+		{name: "last", query: ".nums | last", expected: "7"},
+		{name: "first of empty", query: ".empty | first", expected: "null"},
+		{name: "first matching", query: ".items | first(.on == true) | .name", expected: "b"},
+		{name: "first without match", query: ".items | first(.name == \"z\")", expected: ""},
+		{name: "nth", query: ".nums | nth(2)", expected: "12"},
+		{name: "limit", query: "[limit(2; .nums[])]", expected: "- 3\n- 7"},
+		{name: "range", query: "[range(3)]", expected: "- 0\n- 1\n- 2"},
+		{name: "range with step", query: "[range(5; 0; -2)]", expected: "- 5\n- 3\n- 1"},
+		{name: "any", query: ".flags | any", expected: "true"},
+		{name: "all", query: ".flags | all", expected: "false"},
+		{name: "all of empty", query: ".empty | all", expected: "true"},
+		{name: "any_c", query: ".items | any_c(.name == \"b\")", expected: "true"},
+		{name: "all_c", query: ".items | all_c(.on == true)", expected: "false"},
+		{name: "array index", query: ".nums | index(7)", expected: "1"},
+		{name: "array rindex", query: ".nums | rindex(7)", expected: "3"},
+		{name: "array indices", query: ".nums | indices(7)", expected: "- 1\n- 3"},
+		{name: "sub-array indices", query: ".nums | indices([12, 7])", expected: "- 2"},
+		{name: "string indices", query: ".text | indices(\", \")", expected: "- 3\n- 7"},
+		{name: "string index missing", query: ".text | index(\"zz\")", expected: "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_parse", tt.query, testFile)
+		})
+	}
+}
+
+// TestYqStringParts verifies string literals are split into text and interpolations
+func TestYqStringParts(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "plain", query: `"abc"`, expected: "Sabc"},
+		{name: "escapes kept", query: `"a\"b\n"`, expected: `Sa\"b\n`},
+		{name: "interpolations", query: `"\(.a):\(.b)"`, expected: "E.a\nS:\nE.b"},
+		{name: "nested string", query: `"x\(.a + ")")y"`, expected: "Sx\nE.a + \")\"\nSy"},
+		{name: "escaped backslash", query: `"\\(a)"`, expected: `S\\(a)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_string_parts", tt.query)
+		})
+	}
+
+	for _, query := range []string{`"a" + "b"`, `"\(.a)" | length`, `"open`, `.a`} {
+		tester.ExecuteFunctionExpectError("_yq_string_parts", query)
+	}
+}
+
+func TestYqParseStringInterpolation(t *testing.T) {
+	tester := newYqParseTester(t)
+	defer tester.Cleanup()
+
+	input := "name: app\nversion: 1.2\nmeta:\n  x: 1\nl:\n  - 1\n  - 2"
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{name: "interpolation", query: `"\(.name):\(.version)"`, expected: "app:1.2"},
+		{name: "one string per result", query: `"p-\(.l[])"`, expected: "p-1\n\np-2"},
+		{name: "comma results", query: `"\(.name, .version)"`, expected: "app\n\n\"1.2\""},
+		{name: "every combination", query: `"\(.l[])-\(.name, .version)"`, expected: "1-app\n\n1-1.2\n\n2-app\n\n2-1.2"},
+		{name: "no results", query: `"x\(empty)"`, expected: ""},
+		{name: "nested expression", query: `"\(.name + "-" + "x")!"`, expected: "app-x!"},
+		{name: "nested interpolation", query: `"<\("\(.name)")>"`, expected: "<app>"},
+		{name: "missing value", query: `"v=\(.missing)"`, expected: "v=null"},
+		{name: "map value", query: `"\(.meta)"`, expected: `"x: 1"`},
+		{name: "comma inside", query: `"\(.name), \(.version)"`, expected: "app, 1.2"},
+		{name: "tab escape", query: `"a\tb"`, expected: `"a\tb"`},
+		{name: "newline escape", query: `"a\nb"`, expected: "|-\n  a\n  b"},
+		{name: "quote escape", query: `"say \"hi\""`, expected: `"say \"hi\""`},
+		{name: "unicode escape", query: `"caf\u00e9"`, expected: "café"},
+		{name: "surrogate pair", query: `"\ud83d\ude00"`, expected: "😀"},
+		{name: "literal backslash", query: `"\\(x)"`, expected: `"\\(x)"`},
+		{name: "pipe after string", query: `"\(.name)" | length`, expected: "3"},
+		{name: "concatenation", query: `"a: " + .name`, expected: `"a: app"`},
+		{name: "concatenation with newline", query: `.name + "\n" + .name`, expected: "|-\n  app\n  app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; yq_parse", tt.query, testFile)
+		})
+	}
+}
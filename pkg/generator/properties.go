@@ -0,0 +1,208 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+// GenerateProperties returns the Java properties output and input
+// conversion functions
+func GenerateProperties() string {
+	return `
+# Convert YAML output to properties
+# Input: one or more YAML results separated by blank lines (iteration, comma)
+# Output: one "path = value" line per leaf of every result. The separator is
+# taken from _yq_props_separator and sequence indices are written as [0]
+# when _yq_props_brackets is 1
+yq_yaml_to_props() {
+    _props_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    printf '%s\n' "$1" > "$_props_base.in"
+    _props_count=$(yq_split_results "$_props_base.in" "$_props_base")
+
+    _props_i=0
+    while [ "$_props_i" -lt "$_props_count" ]; do
+        _props_i=$((_props_i + 1))
+        _yq_props "" "$_props_base.$_props_i"
+    done
+    rm -f "$_props_base" "$_props_base".*
+}
+
+# Print the leaves of a node as properties, one "path = value" line each:
+# keys are joined with dots and sequence items use their index
+_yq_props() (
+    _prefix="$1"
+    _file="$2"
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    case "$(yq_node_kind "$_file")" in
+        seq)
+            _count=$(yq_split_items "$_file" "$_base")
+            _i=0
+            while [ "$_i" -lt "$_count" ]; do
+                if [ "${_yq_props_brackets:-0}" -eq 1 ]; then
+                    _yq_props "$_prefix[$_i]" "$_base.$((_i + 1))"
+                else
+                    _yq_props "${_prefix:+$_prefix.}$_i" "$_base.$((_i + 1))"
+                fi
+                _i=$((_i + 1))
+            done
+            ;;
+        map)
+            yq_map_keys "$_file" > "$_base.keys"
+            yq_split_items "$_file" "$_base" > /dev/null
+            _i=0
+            while IFS= read -r _key || [ -n "$_key" ]; do
+                _i=$((_i + 1))
+                _yq_props "${_prefix:+$_prefix.}$_key" "$_base.$_i"
+            done < "$_base.keys"
+            ;;
+        *)
+            yq_scalar_text "$_file" > "$_base.raw"
+            _trail=$(_yq_text_trail "$_base.raw")
+            awk -v trail="$_trail" -v key="$_prefix" -v sep="${_yq_props_separator- = }" '` + awkReadText + `
+            END {
+                t = yq_read_text()
+                gsub(/\\/, "\\\\\\\\", t)
+                gsub(/\n/, "\\n", t)
+                # Spaces, = and : end a key, so they are escaped in keys
+                k = ""
+                for (i = 1; i <= length(key); i++) {
+                    c = substr(key, i, 1)
+                    k = k ((c == " " || c == "=" || c == ":") ? "\\" c : c)
+                }
+                printf "%s%s%s\n", k, sep, t
+            }
+            ' "$_base.raw"
+            ;;
+    esac
+    rm -f "$_base"*
+)
+
+# Parse a properties file into YAML. Keys are split on dots (and [n]
+# indices), numeric segments make sequences and values that read as a
+# number, bool or null stay plain, other values are strings
+yq_props_to_yaml() {
+    LC_ALL=C awk '` + awkUTF8 + awkScalarQuote + `
+    function pad(k,    s) {
+        s = ""
+        while (k-- > 0) s = s " "
+        return s
+    }
+    # Decode the escapes of a key or value, \uXXXX included
+    function unesc(s,    out, i, c) {
+        out = ""
+        for (i = 1; i <= length(s); i++) {
+            c = substr(s, i, 1)
+            if (c != "\\") {
+                out = out c
+                continue
+            }
+            c = substr(s, ++i, 1)
+            if (c == "n") out = out "\n"
+            else if (c == "t") out = out "\t"
+            else if (c == "r") out = out "\r"
+            else if (c == "f") out = out "\f"
+            else if (c == "u") {
+                out = out yq_utf8(yq_hex(substr(s, i + 1, 4)))
+                i += 4
+            }
+            else out = out c
+        }
+        return out
+    }
+    function child(id, seg,    c) {
+        if ((id, seg) in kids) return kids[id, seg]
+        if (cnt[id] == 0) kind[id] = (seg ~ /^[0-9]+$/) ? "seq" : "map"
+        c = ++nodes
+        cnt[c] = 0
+        kids[id, seg] = c
+        order[id, ++cnt[id]] = c
+        name[c] = seg
+        return c
+    }
+    function add(key, value,    segs, n, i, id, k) {
+        gsub(/\[/, ".", key)
+        gsub(/\]/, "", key)
+        n = split(key, segs, ".")
+        id = 0
+        for (i = 1; i <= n; i++) {
+            if (segs[i] == "") continue
+            id = child(id, unesc(segs[i]))
+        }
+        leaf[id] = 1
+        val[id] = unesc(value)
+    }
+    function scalar(v) {
+        if (v ~ /^(true|false|null|-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?)$/) return v
+        return yq_quote(v)
+    }
+    function emit(id, ind, first,    i, c, pre) {
+        for (i = 1; i <= cnt[id]; i++) {
+            c = order[id, i]
+            pre = (i == 1 && first != "") ? first : pad(ind)
+            if (kind[id] == "seq") {
+                if (cnt[c] > 0) emit(c, ind + 2, pre "- ")
+                else print pre "- " scalar(val[c])
+            } else if (cnt[c] > 0) {
+                print pre yq_quote(name[c]) ":"
+                emit(c, ind + 2, "")
+            } else {
+                print pre yq_quote(name[c]) ": " scalar(val[c])
+            }
+        }
+    }
+    BEGIN {
+        nodes = 0
+        cnt[0] = 0
+    }
+    # Split a logical line into its key and value: the key ends at the
+    # first unescaped =, : or whitespace
+    function entry(line,    key, rest, i, c) {
+        key = ""
+        for (i = 1; i <= length(line); i++) {
+            c = substr(line, i, 1)
+            if (c == "\\") {
+                key = key c substr(line, i + 1, 1)
+                i++
+                continue
+            }
+            if (c ~ /[=: \t\f]/) break
+            key = key c
+        }
+        rest = substr(line, i)
+        sub(/^[ \t\f]*/, "", rest)
+        if (rest ~ /^[=:]/) rest = substr(rest, 2)
+        sub(/^[ \t\f]*/, "", rest)
+        add(key, rest)
+    }
+    {
+        line = $0
+        sub(/^[ \t\f]+/, "", line)
+        if (cont == "" && line ~ /^[#!]/) next
+        line = cont line
+        cont = ""
+        # An odd number of trailing backslashes continues the line
+        if (match(line, /\\+$/) && RLENGTH % 2 == 1) {
+            cont = substr(line, 1, length(line) - 1)
+            next
+        }
+        if (line != "") entry(line)
+    }
+    END {
+        if (cont != "") entry(cont)
+        if (cnt[0] == 0) print "{}"
+        else emit(0, 0, "")
+    }
+    ' "$1"
+}
+`
+}
@@ -0,0 +1,82 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"testing"
+)
+
+func newPropertiesTester(t *testing.T) *ShellFunctionTester {
+	return NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateCoreFunctions(),
+		GenerateEncoding(),
+		GenerateProperties(),
+	)
+}
+
+// TestYqProps verifies leaves are written as properties
+func TestYqProps(t *testing.T) {
+	tester := newPropertiesTester(t)
+	defer tester.Cleanup()
+
+	input := "app:\n  name: api\n  \"a key\": \"x=y\"\n  ports:\n    - 80\n    - 443\n  motd: \"a\\nb\""
+
+	tests := []struct {
+		name     string
+		options  string
+		expected string
+	}{
+		{name: "default", options: "", expected: "app.name = api\napp.a\\ key = x=y\napp.ports.0 = 80\napp.ports.1 = 443\napp.motd = a\\nb"},
+		{name: "separator", options: "_yq_props_separator==", expected: "app.name=api\napp.a\\ key=x=y\napp.ports.0=80\napp.ports.1=443\napp.motd=a\\nb"},
+		{name: "array brackets", options: "_yq_props_brackets=1", expected: "app.name = api\napp.a\\ key = x=y\napp.ports[0] = 80\napp.ports[1] = 443\napp.motd = a\\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; "+tt.options+" _yq_props \"\"", testFile)
+		})
+	}
+}
+
+// TestYqPropsToYaml verifies properties files are parsed into a tree
+func TestYqPropsToYaml(t *testing.T) {
+	tester := newPropertiesTester(t)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "nested keys", input: "a.b = 1\na.c = x", expected: "a:\n  b: 1\n  c: x"},
+		{name: "separators", input: "a=1\nb: 2\nc 3", expected: "a: 1\nb: 2\nc: 3"},
+		{name: "sequences", input: "l.0 = x\nl.1.k = y\nl.1.j = z", expected: "l:\n  - x\n  - k: y\n    j: z"},
+		{name: "array brackets", input: "l[0] = x\nl[1] = y", expected: "l:\n  - x\n  - y"},
+		{name: "comments", input: "# c\n! d\n\na = 1", expected: "a: 1"},
+		{name: "continuation", input: "a = one \\\n    two", expected: "a: one two"},
+		{name: "escapes", input: "a\\ b = x\\ty\nc = caf\\u00e9", expected: "a b: \"x\\ty\"\nc: café"},
+		{name: "strings are quoted", input: "a = x: y\nb = \nc = #x", expected: "a: \"x: y\"\nb: \"\"\nc: \"#x\""},
+		{name: "empty file", input: "", expected: "{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.properties", tt.input)
+			tester.ExecuteFunctionExpect(tt.expected, "yq_props_to_yaml", testFile)
+		})
+	}
+}
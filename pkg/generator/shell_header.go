@@ -61,6 +61,20 @@ _yq_debug_indent() {
     >&2 echo "DEBUG[$_depth]$_indent$_msg"
 }
 
+# Raise an error: print it to stderr and record it for try/catch and the
+# exit code, since results travel through files that drop return statuses
+_yq_error() {
+    >&2 echo "Error: $1"
+    [ -n "$_YQ_TEMP_DIR" ] && printf '%s\n' "$1" > "$_YQ_TEMP_DIR/.error"
+    return 1
+}
+
+# Whether an error was raised and not caught: iterations, pipes and
+# collections stop there, since error aborts the whole evaluation
+_yq_raised() {
+    [ -n "$_YQ_TEMP_DIR" ] && [ -f "$_YQ_TEMP_DIR/.error" ]
+}
+
 # Convert JSON arrays to YAML format
 # This allows JSON input to be processed by the YAML parser
 _json_array_to_yaml() {
@@ -0,0 +1,88 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+// GenerateShellOutput returns the shell variable output conversion functions
+func GenerateShellOutput() string {
+	return `
+# Convert YAML output to shell variable assignments
+# Input: one or more YAML results separated by blank lines (iteration, comma)
+# Output: one name=value line per leaf of every result, ready for eval. The
+# names start with _yq_shell_prefix and join the keys with
+# _yq_shell_separator ("_" by default)
+yq_yaml_to_shell() {
+    _shell_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    printf '%s\n' "$1" > "$_shell_base.in"
+    _shell_count=$(yq_split_results "$_shell_base.in" "$_shell_base")
+
+    _shell_i=0
+    while [ "$_shell_i" -lt "$_shell_count" ]; do
+        _shell_i=$((_shell_i + 1))
+        _yq_shell_vars "" "$_shell_base.$_shell_i"
+    done
+    rm -f "$_shell_base" "$_shell_base".*
+}
+
+# Print the leaves of a node as assignments named after their path. Every
+# character of a key, of the key separator or of the prefix that is not
+# allowed in a variable name becomes "_", an empty key and a scalar result
+# are named "value" and a name that would start with a digit gets a leading
+# "_". Values are single-quoted when needed
+_yq_shell_vars() (
+    _name="$1"
+    _file="$2"
+    _sep=$(printf '%s' "${_yq_shell_separator-_}" | LC_ALL=C tr -c 'A-Za-z0-9_' '_')
+    _base=$(mktemp -p "$_YQ_TEMP_DIR")
+
+    case "$(yq_node_kind "$_file")" in
+        seq)
+            _count=$(yq_split_items "$_file" "$_base")
+            _i=0
+            while [ "$_i" -lt "$_count" ]; do
+                _yq_shell_vars "${_name:+$_name$_sep}$_i" "$_base.$((_i + 1))"
+                _i=$((_i + 1))
+            done
+            ;;
+        map)
+            yq_map_keys "$_file" > "$_base.keys"
+            yq_split_items "$_file" "$_base" > /dev/null
+            _i=0
+            while IFS= read -r _key || [ -n "$_key" ]; do
+                _i=$((_i + 1))
+                _key=$(printf '%s' "$_key" | LC_ALL=C tr -c 'A-Za-z0-9_' '_')
+                [ -n "$_key" ] || _key="value"
+                _yq_shell_vars "${_name:+$_name$_sep}$_key" "$_base.$_i"
+            done < "$_base.keys"
+            ;;
+        *)
+            _name="$(printf '%s' "$_yq_shell_prefix" | LC_ALL=C tr -c 'A-Za-z0-9_' '_')${_name:-value}"
+            case "$_name" in
+                [0-9]*) _name="_$_name" ;;
+            esac
+            if [ "$(yq_tag "$_file")" = "!!null" ]; then
+                : > "$_base.raw"
+            else
+                yq_scalar_text "$_file" > "$_base.raw"
+            fi
+            echo >> "$_base.raw"
+            printf '%s=' "$_name"
+            _yq_sh_word "$_base.raw"
+            echo
+            ;;
+    esac
+    rm -f "$_base"*
+)
+`
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"testing"
+)
+
+// TestYqShellVars verifies leaves are written as shell assignments
+func TestYqShellVars(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateCoreFunctions(),
+		GenerateEncoding(),
+		GenerateShellOutput(),
+	)
+	defer tester.Cleanup()
+
+	input := "db:\n  host: localhost\n  log-level: debug\n  password: \"it's\"\n  user:\n  replicas:\n    - a b\n\"1st\": x"
+
+	tests := []struct {
+		name     string
+		options  string
+		expected string
+	}{
+		{name: "default", options: "", expected: "db_host=localhost\ndb_log_level=debug\ndb_password='it'\\''s'\ndb_user=''\ndb_replicas_0='a b'\n_1st=x"},
+		{name: "prefix", options: "_yq_shell_prefix=CFG_", expected: "CFG_db_host=localhost\nCFG_db_log_level=debug\nCFG_db_password='it'\\''s'\nCFG_db_user=''\nCFG_db_replicas_0='a b'\nCFG_1st=x"},
+		{name: "separator", options: "_yq_shell_separator=__", expected: "db__host=localhost\ndb__log_level=debug\ndb__password='it'\\''s'\ndb__user=''\ndb__replicas__0='a b'\n_1st=x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_init_temp_dir; "+tt.options+" _yq_shell_vars \"\"", testFile)
+		})
+	}
+
+	t.Run("empty keys and separator characters", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "\"\": 2\na:\n  \"\": 3\n  b: 4")
+		tester.ExecuteFunctionExpect("value=2\na_value=3\na_b=4", "_yq_init_temp_dir; _yq_shell_separator=. _yq_shell_vars \"\"", testFile)
+	})
+
+	t.Run("prefix characters", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "top: date")
+		tester.ExecuteFunctionExpect("my_top=date", "_yq_init_temp_dir; _yq_shell_prefix=my- _yq_shell_vars \"\"", testFile)
+	})
+
+	t.Run("scalar result", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", "date")
+		tester.ExecuteFunctionExpect("value=date", "_yq_init_temp_dir; _yq_shell_vars \"\"", testFile)
+	})
+
+	t.Run("assignments can be evaluated", func(t *testing.T) {
+		testFile := tester.WriteFile("test.yaml", input)
+		tester.ExecuteFunctionExpect("it's|a b", "_yq_init_temp_dir; eval \"$(_yq_shell_vars \"\" \""+testFile+"\")\"; printf '%s|%s' \"$db_password\" \"$db_replicas_0\"; :")
+	})
+}
@@ -89,8 +89,13 @@ func (sft *ShellFunctionTester) ExecuteFunction(funcName string, args ...string)
 	}
 	shellScript += "\n"
 
-	// Execute the shell script
-	cmd := exec.Command("sh", "-c", shellScript)
+	// Execute the shell script from a file: the generated script is larger
+	// than a single command line argument may be
+	scriptPath := filepath.Join(sft.tmpDir, "script.sh")
+	if err := os.WriteFile(scriptPath, []byte(shellScript), 0644); err != nil {
+		return "", err
+	}
+	cmd := exec.Command("sh", scriptPath)
 	output, err := cmd.CombinedOutput()
 	return string(output), err
 }
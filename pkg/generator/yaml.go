@@ -0,0 +1,463 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+// GenerateYAML returns the YAML output serializer
+func GenerateYAML() string {
+	return `
+# Render YAML output with a canonical layout
+# Input: one or more YAML results separated by blank lines, the indentation
+# width, 1 to pretty print and 1 to unwrap scalars
+# Output: every result parsed and written again with the same indentation
+# everywhere, sequences indented under their key and keys in their order
+yq_yaml_emit() {
+    _emit_base=$(mktemp -p "$_YQ_TEMP_DIR")
+    printf '%s\n' "$1" > "$_emit_base.in"
+    _emit_count=$(yq_split_results "$_emit_base.in" "$_emit_base")
+
+    _emit_i=0
+    while [ "$_emit_i" -lt "$_emit_count" ]; do
+        _emit_i=$((_emit_i + 1))
+        if [ "${4:-0}" -eq 1 ] && [ "$(yq_node_kind "$_emit_base.$_emit_i")" = "scalar" ] && ! _yq_is_flow_collection "$_emit_base.$_emit_i"; then
+            # An unwrapped scalar is printed as its text, multi-line strings
+            # and block scalars as their lines
+            printf '%s\n' "$(yq_scalar_text "$_emit_base.$_emit_i")"
+        else
+            _yq_node_to_yaml "${2:-2}" "${3:-0}" "$_emit_base.$_emit_i"
+        fi
+    done
+    rm -f "$_emit_base" "$_emit_base".*
+}
+
+# Succeed when a node (file) is a flow collection ([1, 2] or {a: 1}), which
+# yq_node_kind reads as a scalar
+_yq_is_flow_collection() {
+    awk '
+    /^[[:space:]]*(#.*)?$/ || /^(---|\.\.\.)/ {
+        next
+    }
+    {
+        flow = ($0 ~ /^[[:space:]]*(![^ ]* +)?[[{]/)
+        exit
+    }
+    END {
+        exit !flow
+    }
+    ' "$1"
+}
+
+# Write a single YAML node (file) with the given indentation width (2 when
+# it is 0, nesting needs at least one space). Without pretty printing
+# scalars and flow collections keep their style; with it flow collections
+# become block ones, quoted strings are only quoted when they need to be and
+# multi-line strings become literal blocks
+_yq_node_to_yaml() {
+    LC_ALL=C awk -v width="$1" -v pretty="$2" '` + awkMapKey + awkUTF8 + awkScalarQuote + awkBlockScalarDecode + `
+    BEGIN {
+        if (width < 1) width = 2
+    }
+    {
+        raw[++n] = $0
+        t = $0
+        sub(/^ */, "", t)
+        li[n] = length($0) - length(t)
+        sub(/[ \t]+$/, "", t)
+        lt[n] = t
+    }
+    function pad(k,    s) {
+        s = ""
+        while (k-- > 0) s = s " "
+        return s
+    }
+    # Move to the next line with content, comments are kept for the next
+    # entry or item
+    function skip() {
+        while (ln <= n && (lt[ln] == "" || lt[ln] ~ /^#/)) {
+            if (lt[ln] != "") pend = (pend == "") ? lt[ln] : pend "\n" lt[ln]
+            ln++
+        }
+    }
+    function is_item(t) {
+        return t ~ /^-( |$)/
+    }
+    function is_entry(t) {
+        return !is_item(t) && (yq_line_key(t) != "" || yq_line_is_key)
+    }
+    function node(k,    id) {
+        id = ++nodes
+        kind[id] = k
+        cnt[id] = 0
+        val[id] = ""
+        prop[id] = ""
+        lc[id] = ""
+        return id
+    }
+    # Split a trailing comment from a scalar, quoted text is skipped first
+    function cut_comment(t,    head, p) {
+        cmt = ""
+        head = ""
+        if (t ~ /^"/ && match(t, /^"([^"\\]|\\.)*"/)) head = substr(t, 1, RLENGTH)
+        else if (t ~ /^'"'"'/ && match(t, /^'"'"'([^'"'"']|'"'"''"'"')*'"'"'/)) head = substr(t, 1, RLENGTH)
+        else if (t ~ /^#/) {
+            cmt = t
+            return ""
+        }
+        p = index(substr(t, length(head) + 1), " #")
+        if (p == 0) return t
+        cmt = substr(t, length(head) + p + 1)
+        t = substr(t, 1, length(head) + p - 1)
+        sub(/[ \t]+$/, "", t)
+        return t
+    }
+    # Parse the node starting at the current line, owner is the indentation
+    # of the entry or item it belongs to
+    function parse_block(owner,    t, id) {
+        skip()
+        t = lt[ln]
+        if (is_item(t)) return parse_seq(li[ln])
+        if (is_entry(t)) return parse_map(li[ln])
+        ln++
+        return parse_value(t, owner)
+    }
+    function parse_map(ind,    id, i, t, k, rest) {
+        id = node("map")
+        while (ln <= n) {
+            skip()
+            if (ln > n || li[ln] != ind || !is_entry(lt[ln])) break
+            t = lt[ln]
+            yq_line_key(t)
+            rest = yq_line_value
+            k = substr(t, 1, length(t) - length(rest))
+            sub(/[ \t]*$/, "", k)
+            sub(/[ \t]*:$/, "", k)
+            i = ++cnt[id]
+            key[id, i] = k
+            head[id, i] = pend
+            pend = ""
+            ln++
+            kid[id, i] = parse_value(rest, ind, 1)
+        }
+        return id
+    }
+    function parse_seq(ind,    id, i, t, rest) {
+        id = node("seq")
+        while (ln <= n) {
+            skip()
+            if (ln > n || li[ln] != ind || !is_item(lt[ln])) break
+            t = lt[ln]
+            i = ++cnt[id]
+            head[id, i] = pend
+            pend = ""
+            rest = substr(t, 2)
+            match(rest, /^ */)
+            rest = substr(rest, RLENGTH + 1)
+            if (is_item(rest) || is_entry(rest)) {
+                # Compact nested collection: the rest of the line is parsed
+                # as if it started its own line
+                li[ln] = ind + length(t) - length(rest)
+                lt[ln] = rest
+                kid[id, i] = parse_block(ind)
+            } else {
+                ln++
+                kid[id, i] = parse_value(rest, ind, 0)
+            }
+        }
+        return id
+    }
+    # Parse the value written after "key:" or "- " (the line is consumed).
+    # An empty value is followed by a nested block or is null
+    function parse_value(t, owner, in_map,    id, p, c) {
+        p = ""
+        while (match(t, /^[!&][^ \t]*/)) {
+            p = (p == "") ? substr(t, 1, RLENGTH) : p " " substr(t, 1, RLENGTH)
+            t = substr(t, RLENGTH + 1)
+            sub(/^[ \t]+/, "", t)
+        }
+        if (t == "" || t ~ /^#/) {
+            c = t
+            skip()
+            if (ln <= n && (li[ln] > owner || (in_map && li[ln] == owner && is_item(lt[ln])))) {
+                id = parse_block(owner)
+            } else {
+                id = node("scalar")
+            }
+            lc[id] = c
+        } else if (t ~ /^[|>]/) {
+            id = parse_block_scalar(t, owner)
+        } else if (t ~ /^[\[{]/) {
+            id = parse_flow_text(t, owner)
+        } else {
+            id = node("scalar")
+            val[id] = cut_comment(t)
+            lc[id] = cmt
+            # Plain and quoted scalars may continue on more indented lines
+            while (ln <= n && lt[ln] != "" && lt[ln] !~ /^#/ && li[ln] > owner) {
+                val[id] = val[id] " " lt[ln]
+                ln++
+            }
+        }
+        prop[id] = p
+        return id
+    }
+    function parse_block_scalar(t, owner,    id, ind, j, k, body) {
+        id = node("block")
+        t = cut_comment(t)
+        lc[id] = cmt
+        ind = 0
+        if (match(t, /[1-9]/)) {
+            ind = owner + substr(t, RSTART, 1)
+            t = substr(t, 1, RSTART - 1) substr(t, RSTART + 1)
+        }
+        val[id] = t
+        k = 0
+        while (ln <= n && (lt[ln] == "" || li[ln] > owner)) {
+            if (ind == 0 && lt[ln] != "") ind = li[ln]
+            body[++k] = raw[ln]
+            ln++
+        }
+        # Blank lines after the body belong to the next node
+        while (k > 0 && body[k] ~ /^[ \t]*$/ && t !~ /\+/) k--
+        if (ind < 0) ind = 0
+        for (j = 1; j <= k; j++) {
+            bl[id, j] = (body[j] ~ /^[ \t]*$/ && length(body[j]) <= ind) ? "" : substr(body[j], ind + 1)
+        }
+        cnt[id] = k
+        return id
+    }
+    # Collect a flow collection that may span several lines
+    function parse_flow_text(t, owner,    id, f) {
+        f = t
+        while (flow_depth(f) > 0 && ln <= n) {
+            f = f " " lt[ln]
+            ln++
+        }
+        id = node("flow")
+        val[id] = cut_comment(f)
+        lc[id] = cmt
+        if (pretty) {
+            fs_ = val[id]
+            fp = 1
+            fl = length(fs_)
+            id = parse_flow()
+            lc[id] = cmt
+        }
+        return id
+    }
+    function flow_depth(f,    i, c, d, q) {
+        d = 0
+        q = ""
+        for (i = 1; i <= length(f); i++) {
+            c = substr(f, i, 1)
+            if (q != "") {
+                if (c == "\\" && q == "\"") i++
+                else if (c == q) q = ""
+            } else if (c == "\"" || c == "'"'"'") q = c
+            else if (c == "[" || c == "{") d++
+            else if (c == "]" || c == "}") d--
+            else if (c == "#" && substr(f, i - 1, 1) == " ") break
+        }
+        return d
+    }
+    function flow_ws() {
+        while (fp <= fl && substr(fs_, fp, 1) ~ /[ \t]/) fp++
+    }
+    function parse_flow(    id, c, i) {
+        flow_ws()
+        c = substr(fs_, fp, 1)
+        if (c == "[" || c == "{") {
+            id = node(c == "[" ? "seq" : "map")
+            fp++
+            while (fp <= fl) {
+                flow_ws()
+                c = substr(fs_, fp, 1)
+                if (c == "]" || c == "}") {
+                    fp++
+                    break
+                }
+                if (c == ",") {
+                    fp++
+                    continue
+                }
+                i = ++cnt[id]
+                head[id, i] = ""
+                if (kind[id] == "seq") {
+                    kid[id, i] = parse_flow()
+                    continue
+                }
+                key[id, i] = flow_scalar()
+                flow_ws()
+                if (substr(fs_, fp, 1) == ":") {
+                    fp++
+                    kid[id, i] = parse_flow()
+                } else {
+                    kid[id, i] = node("scalar")
+                }
+            }
+            return id
+        }
+        id = node("scalar")
+        val[id] = flow_scalar()
+        return id
+    }
+    # Read a quoted or plain scalar inside a flow collection
+    function flow_scalar(    c, q, s, start) {
+        flow_ws()
+        start = fp
+        q = substr(fs_, fp, 1)
+        if (q == "\"" || q == "'"'"'") {
+            fp++
+            while (fp <= fl) {
+                c = substr(fs_, fp, 1)
+                if (c == "\\" && q == "\"") fp++
+                else if (c == q && q == "'"'"'" && substr(fs_, fp + 1, 1) == q) fp++
+                else if (c == q) break
+                fp++
+            }
+            fp++
+            return substr(fs_, start, fp - start)
+        }
+        while (fp <= fl) {
+            c = substr(fs_, fp, 1)
+            if (c ~ /[,\]}]/) break
+            if (c == ":" && substr(fs_, fp + 1, 1) ~ /^([ \t,\]}]|)$/) break
+            fp++
+        }
+        s = substr(fs_, start, fp - start)
+        sub(/[ \t]+$/, "", s)
+        return s
+    }
+    # Pretty printing decodes quoted strings and writes them again, a
+    # multi-line string becomes a literal block scalar
+    function restyle(id,    v, lines, k, chomp, j) {
+        if (kind[id] == "block") {
+            for (j = 1; j <= cnt[id]; j++) lines[j] = bl[id, j]
+            chomp = (val[id] ~ /-/) ? "-" : (val[id] ~ /\+/) ? "+" : ""
+            v = yq_block_decode(lines, cnt[id], substr(val[id], 1, 1), chomp)
+        } else if (kind[id] == "scalar" && val[id] ~ /^".*"$/) {
+            v = yq_unescape(substr(val[id], 2, length(val[id]) - 2))
+        } else if (kind[id] == "scalar" && val[id] ~ /^'"'"'.*'"'"'$/) {
+            v = substr(val[id], 2, length(val[id]) - 2)
+            gsub(/'"'"''"'"'/, "'"'"'", v)
+        } else {
+            return
+        }
+        if (index(v, "\n") && v ~ /[^\n]/ && v !~ /^[ \t]/) {
+            kind[id] = "block"
+            chomp = (v !~ /\n$/) ? "-" : (v ~ /\n\n$/) ? "+" : ""
+            if (chomp != "-") sub(/\n$/, "", v)
+            val[id] = "|" chomp
+            cnt[id] = split(v, lines, "\n")
+            for (j = 1; j <= cnt[id]; j++) bl[id, j] = lines[j]
+        } else {
+            kind[id] = "scalar"
+            val[id] = yq_quote(v)
+        }
+    }
+    function restyle_key(k,    v) {
+        if (k ~ /^".*"$/) v = yq_unescape(substr(k, 2, length(k) - 2))
+        else if (k ~ /^'"'"'.*'"'"'$/) {
+            v = substr(k, 2, length(k) - 2)
+            gsub(/'"'"''"'"'/, "'"'"'", v)
+        } else return k
+        return yq_quote(v)
+    }
+    function out_comments(c, ind,    lines, k, j) {
+        if (c == "") return
+        k = split(c, lines, "\n")
+        for (j = 1; j <= k; j++) print pad(ind) lines[j]
+    }
+    function is_nested(id) {
+        return (kind[id] == "map" || kind[id] == "seq") && cnt[id] > 0
+    }
+    # The text written after "key:" or "-" for a node that stays on the line
+    function inline(id,    v) {
+        if (pretty) restyle(id)
+        if (kind[id] == "map" || kind[id] == "seq") v = (kind[id] == "map") ? "{}" : "[]"
+        else if (kind[id] == "block" && bl[id, 1] ~ /^[ \t]/) v = substr(val[id], 1, 1) width substr(val[id], 2)
+        else v = val[id]
+        if (prop[id] != "") v = (v == "") ? prop[id] : prop[id] " " v
+        if (lc[id] != "") v = (v == "") ? lc[id] : v " " lc[id]
+        return (v == "") ? "" : " " v
+    }
+    # Write the body lines of a block scalar one level below ind
+    function emit_body(id, ind,    j) {
+        for (j = 1; j <= cnt[id]; j++) print (bl[id, j] == "" ? "" : pad(ind + width) bl[id, j])
+    }
+    function header(id,    p) {
+        p = prop[id]
+        if (lc[id] != "") p = (p == "") ? lc[id] : p " " lc[id]
+        return (p == "") ? "" : " " p
+    }
+    # Write collection id at indentation ind, first replaces the indentation
+    # of the first line (the "- " of a sequence item holding a collection)
+    function emit(id, ind, first,    i, c, pre, k) {
+        if (!is_nested(id)) {
+            print ((first == "") ? pad(ind) : first) substr(inline(id), 2)
+            return
+        }
+        for (i = 1; i <= cnt[id]; i++) {
+            out_comments(head[id, i], ind)
+            pre = (i == 1 && first != "") ? first : pad(ind)
+            c = kid[id, i]
+            if (kind[id] == "seq") {
+                if (is_nested(c) && prop[c] == "" && lc[c] == "") {
+                    emit(c, ind + 2, pre "- ")
+                } else if (is_nested(c)) {
+                    print pre "-" header(c)
+                    emit(c, ind + 2, "")
+                } else {
+                    k = inline(c)
+                    print pre "-" k
+                    if (kind[c] == "block") emit_body(c, ind)
+                }
+                continue
+            }
+            k = (pretty) ? restyle_key(key[id, i]) : key[id, i]
+            if (is_nested(c)) {
+                print pre k ":" header(c)
+                emit(c, ind + width, "")
+            } else {
+                print pre k ":" inline(c)
+                if (kind[c] == "block") emit_body(c, ind)
+            }
+        }
+    }
+    END {
+        ln = 1
+        pend = ""
+        skip()
+        while (ln <= n) {
+            if (lt[ln] == "---" && li[ln] == 0) {
+                print "---"
+                ln++
+                skip()
+                continue
+            }
+            out_comments(pend, 0)
+            pend = ""
+            if (!is_item(lt[ln]) && !is_entry(lt[ln]) && !(pretty && lt[ln] ~ /^[\[{]/)) {
+                # Scalars (already unwrapped) and flow collections are kept
+                for (; ln <= n; ln++) print raw[ln]
+                break
+            }
+            emit(parse_block(-1), 0, "")
+            skip()
+        }
+        out_comments(pend, 0)
+    }
+    ' "$3"
+}
+`
+}
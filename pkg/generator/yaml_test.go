@@ -0,0 +1,62 @@
+// Copyright 2025 Alexandre Mahdhaoui
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generator
+
+import (
+	"testing"
+)
+
+// TestYqNodeToYAML verifies nodes are written again with one indentation
+func TestYqNodeToYAML(t *testing.T) {
+	tester := NewShellFunctionTesterWithDeps(t,
+		GenerateShellHeader(),
+		GenerateCoreFunctions(),
+		GenerateYAML(),
+	)
+	defer tester.Cleanup()
+
+	tests := []struct {
+		name     string
+		input    string
+		indent   string
+		pretty   string
+		expected string
+	}{
+		{name: "sequence under key is indented", input: "a:\n- 1\n- 2", indent: "2", pretty: "0", expected: "a:\n  - 1\n  - 2"},
+		{name: "mixed indentation", input: "a:\n    b:\n         c: 1", indent: "2", pretty: "0", expected: "a:\n  b:\n    c: 1"},
+		{name: "indent 0 keeps nesting", input: "a:\n  b: 1\n  l:\n  - x", indent: "0", pretty: "0", expected: "a:\n  b: 1\n  l:\n    - x"},
+		{name: "indent 4", input: "a:\n  b: 1\n  l:\n  - x: 1\n    y: 2", indent: "4", pretty: "0", expected: "a:\n    b: 1\n    l:\n        - x: 1\n          y: 2"},
+		{name: "nested sequences", input: "- - a\n  - b\n- c", indent: "2", pretty: "0", expected: "- - a\n  - b\n- c"},
+		{name: "block scalar body", input: "s: |-\n    x\n\n    y\nn: 1", indent: "2", pretty: "0", expected: "s: |-\n  x\n\n  y\nn: 1"},
+		{name: "block scalar with leading spaces", input: "s: |2\n   x\n  y", indent: "4", pretty: "0", expected: "s: |4\n     x\n    y"},
+		{name: "comments are kept", input: "# head\na: 1 # one\n# before b\nb: 2", indent: "2", pretty: "0", expected: "# head\na: 1 # one\n# before b\nb: 2"},
+		{name: "flow kept", input: "a: {b: 1, c: [x, y]}", indent: "2", pretty: "0", expected: "a: {b: 1, c: [x, y]}"},
+		{name: "quotes kept", input: "a: \"x\"\n'b': 'y'", indent: "2", pretty: "0", expected: "a: \"x\"\n'b': 'y'"},
+		{name: "properties", input: "a: &x\n  b: 1\nc: !!str 1", indent: "2", pretty: "0", expected: "a: &x\n  b: 1\nc: !!str 1"},
+		{name: "pretty flow to block", input: "a: {b: 1, c: [x, \"y z\"], d: []}", indent: "2", pretty: "1", expected: "a:\n  b: 1\n  c:\n    - x\n    - y z\n  d: []"},
+		{name: "pretty multi-line flow", input: "a: [\n  1,\n  2\n]", indent: "2", pretty: "1", expected: "a:\n  - 1\n  - 2"},
+		{name: "pretty drops needless quotes", input: "a: \"x\"\n\"b\": 'it''s'\nc: \"1.0\"", indent: "2", pretty: "1", expected: "a: x\nb: it's\nc: \"1.0\""},
+		{name: "pretty multi-line string", input: "a: \"x\\ny\"", indent: "2", pretty: "1", expected: "a: |-\n  x\n  y"},
+		{name: "pretty folded block", input: "a: >\n  x\n  y", indent: "2", pretty: "1", expected: "a: |\n  x y"},
+		{name: "scalar result kept", input: "hello world", indent: "4", pretty: "1", expected: "hello world"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := tester.WriteFile("test.yaml", tt.input)
+			tester.ExecuteFunctionExpect(tt.expected, "_yq_node_to_yaml", tt.indent, tt.pretty, testFile)
+		})
+	}
+}
@@ -83,6 +83,12 @@ for scenario_dir in "$SCENARIOS_DIR"/[0-9][0-9]-*; do
         fi
     fi
 
+    # input2.yaml is a second file, for eval-all across files
+    INPUT_FILE2=""
+    if [ -f "${scenario_dir}/input2.yaml" ]; then
+        INPUT_FILE2="${scenario_dir}/input2.yaml"
+    fi
+
     # Expected output could be .yaml, .json, or .txt (for error messages)
     EXPECTED_OUTPUT=""
     if [ -f "${scenario_dir}/output.yaml" ] && [ -s "${scenario_dir}/output.yaml" ]; then
@@ -121,14 +127,19 @@ for scenario_dir in "$SCENARIOS_DIR"/[0-9][0-9]-*; do
     EXPRESSION=""
 
     case "$COMMAND" in
-        -o\ *|--output-format\ *)
-            # Command starts with output format flag (e.g., "-o json")
-            # Extract the flag and its value
-            if [ "${COMMAND#-o }" != "$COMMAND" ]; then
-                FLAGS="-o ${COMMAND#-o }"
-                # For format-only commands, expression is "." (identity)
-                EXPRESSION="."
-            fi
+        -*)
+            # Command starts with flags (e.g., "-o json" or "-P -I 4 '.a'")
+            # The flags end at the quoted expression, which defaults to "."
+            case "$COMMAND" in
+                *\'*|*\"*)
+                    FLAGS="${COMMAND%%[\'\"]*}"
+                    EXPRESSION="${COMMAND#"$FLAGS"}"
+                    ;;
+                *)
+                    FLAGS="$COMMAND"
+                    EXPRESSION="."
+                    ;;
+            esac
             ;;
         eval-all\ *)
             SUBCOMMAND="eval-all"
@@ -161,11 +172,11 @@ for scenario_dir in "$SCENARIOS_DIR"/[0-9][0-9]-*; do
     if [ -n "$INPUT_FILE" ]; then
         if [ -n "$FLAGS" ]; then
             # shellcheck disable=SC2086
-            "$POSIX_YQ" $FLAGS "$EXPRESSION" "$INPUT_FILE" >"$ACTUAL_OUTPUT" 2>"$ERROR_OUTPUT" || EXIT_CODE=$?
+            "$POSIX_YQ" $FLAGS "$EXPRESSION" "$INPUT_FILE" ${INPUT_FILE2:+"$INPUT_FILE2"} >"$ACTUAL_OUTPUT" 2>"$ERROR_OUTPUT" || EXIT_CODE=$?
         elif [ -n "$SUBCOMMAND" ]; then
-            "$POSIX_YQ" "$SUBCOMMAND" "$EXPRESSION" "$INPUT_FILE" >"$ACTUAL_OUTPUT" 2>"$ERROR_OUTPUT" || EXIT_CODE=$?
+            "$POSIX_YQ" "$SUBCOMMAND" "$EXPRESSION" "$INPUT_FILE" ${INPUT_FILE2:+"$INPUT_FILE2"} >"$ACTUAL_OUTPUT" 2>"$ERROR_OUTPUT" || EXIT_CODE=$?
         else
-            "$POSIX_YQ" "$EXPRESSION" "$INPUT_FILE" >"$ACTUAL_OUTPUT" 2>"$ERROR_OUTPUT" || EXIT_CODE=$?
+            "$POSIX_YQ" "$EXPRESSION" "$INPUT_FILE" ${INPUT_FILE2:+"$INPUT_FILE2"} >"$ACTUAL_OUTPUT" 2>"$ERROR_OUTPUT" || EXIT_CODE=$?
         fi
     else
         if [ -n "$FLAGS" ]; then